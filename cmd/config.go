@@ -0,0 +1,25 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/sudo-hassan-zahid/go-api-server/internal/config"
+)
+
+// runConfig prints the loaded configuration with secrets redacted, along
+// with the env key of each value and the layer it came from.
+func runConfig(cfg *config.Config, args []string) error {
+	if len(args) != 1 || args[0] != "print" {
+		return errors.New("usage: config print")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SETTING\tKEY\tVALUE\tSOURCE")
+	for _, f := range cfg.Fields() {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", f.Path, f.Key, f.Value, f.Source)
+	}
+	return w.Flush()
+}
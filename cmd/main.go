@@ -2,15 +2,10 @@ package main
 
 import (
 	"context"
-	"log"
+	"errors"
+	"flag"
+	"fmt"
 	"os"
-	"os/signal"
-	"syscall"
-	"time"
-
-	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/logger"
-	"github.com/gofiber/fiber/v2/middleware/recover"
 
 	_ "github.com/sudo-hassan-zahid/go-api-server/docs"
 	"github.com/sudo-hassan-zahid/go-api-server/internal/auth"
@@ -18,12 +13,40 @@ import (
 	"github.com/sudo-hassan-zahid/go-api-server/internal/constants"
 	"github.com/sudo-hassan-zahid/go-api-server/internal/database"
 	appLogger "github.com/sudo-hassan-zahid/go-api-server/internal/logger"
-	"github.com/sudo-hassan-zahid/go-api-server/internal/middleware"
-	"github.com/sudo-hassan-zahid/go-api-server/internal/models"
-	"github.com/sudo-hassan-zahid/go-api-server/routes"
-	swagger "github.com/swaggo/fiber-swagger"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/repository"
+	"gorm.io/gorm"
 )
 
+type command struct {
+	name    string
+	summary string
+	run     func(cfg *config.Config, args []string) error
+}
+
+// loadOptions holds the config flags given before the command. The config
+// watcher reuses them on reload.
+var loadOptions config.Options
+
+var commands = []command{
+	{"serve", "start the HTTP server (default)", runServe},
+	{"migrate", "apply, roll back or create schema migrations", runMigrate},
+	{"seed", "load fixture data from a profile or file", runSeed},
+	{"user", "create users and change roles", runUser},
+	{"token", "mint access tokens for debugging", runToken},
+	{"config", "print the effective configuration", runConfig},
+}
+
+func usage(fs *flag.FlagSet) {
+	fmt.Fprintln(os.Stderr, "usage: go-api-server [config flags] <command> [arguments]")
+	fmt.Fprintln(os.Stderr)
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-9s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "config flags:")
+	fs.PrintDefaults()
+}
+
 // @title            				Go API Server
 // @version          				1.0
 // @description      				This API server is powered by Go. Using PostgreSQL for DB with a magical touch of GORM
@@ -33,82 +56,82 @@ import (
 // @name 							Authorization
 // @description 					Type "Bearer" followed by your JWT token.
 func main() {
+	fs := flag.NewFlagSet("go-api-server", flag.ContinueOnError)
+	opts := config.BindFlags(fs)
+	fs.Usage = func() { usage(fs) }
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		os.Exit(2)
+	}
+
+	name, args := "serve", fs.Args()
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		usage(fs)
+		return
+	}
+
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == name {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		usage(fs)
+		os.Exit(2)
+	}
+
 	// Load config
-	cfg, err := config.Load()
+	loadOptions = *opts
+	cfg, err := config.LoadWithOptions(loadOptions)
 	if err != nil {
-		panic(err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 
 	// Initialize Logger
 	appLogger.Init(cfg.Log, cfg.App.Environment)
 
-	// Connect to Database
+	if err := cmd.run(cfg, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+		os.Exit(1)
+	}
+}
+
+// connectDB opens the database the same way for every command. The returned
+// func closes the pool.
+func connectDB(cfg *config.Config) (*gorm.DB, func(), error) {
 	db, err := database.Connect(cfg.DB, cfg.App.Environment == constants.ENV_LOCAL)
 	if err != nil {
-		appLogger.Log.Fatal().Err(err).Msg("Failed to connect to database")
+		return nil, nil, fmt.Errorf("connect to database: %w", err)
 	}
 
-	// Auto-migrate dev models
-	if cfg.App.Environment == constants.ENV_LOCAL {
-		if err := db.AutoMigrate(&models.User{}); err != nil {
-			log.Fatal("AutoMigrate failed:", err)
+	closeDB := func() {
+		sqlDB, _ := db.DB()
+		if err := sqlDB.Close(); err != nil {
+			appLogger.Log.Error().Err(err).Msg("Failed to close database connection")
+		} else {
+			appLogger.Log.Debug().Msg("Database connection closed")
 		}
 	}
+	return db, closeDB, nil
+}
 
-	// Initialize Fiber App
-	app := fiber.New(fiber.Config{
-		AppName:      cfg.App.Name,
-		ReadTimeout:  20 * time.Second,
-		WriteTimeout: 20 * time.Second,
-	})
-
-	// Middlewares
-	app.Use(logger.New())
-	app.Use(recover.New())
-	app.Use(middleware.ErrorLogger())
-
-	// Auth init
-	auth.Init(cfg)
-
-	// Routes
-	routes.Setup(app, db)
-
-	// Swagger docs
-	app.Get("/swagger/*", swagger.FiberWrapHandler())
-
-	// Start Server in Goroutine
-	serverErrors := make(chan error, 1)
-	go func() {
-		appLogger.Log.Info().Str("port", cfg.App.Port).Msg("Starting Fiber server")
-		serverErrors <- app.Listen(":" + cfg.App.Port)
-	}()
-
-	// Graceful Shutdown
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-
-	select {
-	case sig := <-quit:
-		appLogger.Log.Info().Str("signal", sig.String()).Msg("Shutting down server...")
-	case err := <-serverErrors:
-		appLogger.Log.Fatal().Err(err).Msg("Server failed")
-	}
-
-	// Fiber shutdown with timeout context
-	_, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	if err := app.Shutdown(); err != nil {
-		appLogger.Log.Error().Err(err).Msg("Error during server shutdown")
-	} else {
-		appLogger.Log.Info().Msg("Server gracefully stopped")
+// initAuth sets up signing keys, revocation checks and the role table.
+func initAuth(cfg *config.Config, db *gorm.DB) error {
+	if err := auth.Init(cfg, repository.NewRevocationRepository(db)); err != nil {
+		return fmt.Errorf("initialize auth: %w", err)
 	}
-
-	// Close DB connection
-	sqlDB, _ := db.DB()
-	if err := sqlDB.Close(); err != nil {
-		appLogger.Log.Error().Err(err).Msg("Failed to close database connection")
-	} else {
-		appLogger.Log.Info().Msg("Database connection closed")
+	if err := auth.LoadRolePermissions(context.Background(), repository.NewRoleRepository(db)); err != nil {
+		return fmt.Errorf("load role permissions: %w", err)
 	}
+	return nil
 }
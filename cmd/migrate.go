@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/sudo-hassan-zahid/go-api-server/internal/config"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/database"
+)
+
+const migrateUsage = `usage: migrate <command>
+
+  up [N]          apply all pending migrations, or the next N
+  down [N]        roll back the last migration, or the last N
+  status          list migrations and when they were applied
+  create <name>   write a new empty up/down pair to -dir`
+
+func runMigrate(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dir := fs.String("dir", database.MigrationsDir, "directory for new migration files")
+	fs.Usage = func() { fmt.Fprintln(fs.Output(), migrateUsage) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("missing migrate command")
+	}
+
+	command, rest := fs.Arg(0), fs.Args()[1:]
+	if command == "create" {
+		if len(rest) != 1 {
+			return errors.New("usage: migrate create <name>")
+		}
+		up, down, err := database.CreateMigration(*dir, rest[0])
+		if err != nil {
+			return err
+		}
+		fmt.Println(up)
+		fmt.Println(down)
+		return nil
+	}
+
+	db, closeDB, err := connectDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch command {
+	case "up":
+		steps, err := migrateSteps(rest, 0)
+		if err != nil {
+			return err
+		}
+		applied, err := migrator.Up(ctx, steps)
+		for _, m := range applied {
+			fmt.Printf("applied  %04d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+		return err
+	case "down":
+		steps, err := migrateSteps(rest, 1)
+		if err != nil {
+			return err
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted %04d_%s\n", m.Version, m.Name)
+		}
+		return err
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range status {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown migrate command %q", command)
+	}
+}
+
+func migrateSteps(args []string, fallback int) (int, error) {
+	if len(args) == 0 {
+		return fallback, nil
+	}
+	steps, err := strconv.Atoi(args[0])
+	if err != nil || steps < 1 {
+		return 0, fmt.Errorf("invalid step count %q", args[0])
+	}
+	return steps, nil
+}
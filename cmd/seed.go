@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/sudo-hassan-zahid/go-api-server/internal/config"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/constants"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/seed"
+)
+
+func runSeed(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	profile := fs.String("profile", "", "named fixture set: demo or loadtest")
+	file := fs.String("file", "", "path to a .yaml or .json fixture file")
+	n := fs.Int("n", seed.DefaultLoadTestUsers, "number of users for the loadtest profile")
+	force := fs.Bool("force", false, "allow the built-in profiles in prod")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if (*profile == "") == (*file == "") {
+		fs.Usage()
+		return errors.New("exactly one of -profile and -file is required")
+	}
+	// The built-in profiles create accounts with well-known passwords.
+	if *profile != "" && cfg.App.Environment == constants.ENV_PROD && !*force {
+		return fmt.Errorf("refusing to seed the %s profile in %s without -force", *profile, constants.ENV_PROD)
+	}
+
+	var fixtures *seed.Fixtures
+	var err error
+	if *file != "" {
+		fixtures, err = seed.LoadFile(*file)
+	} else {
+		fixtures, err = seed.Profile(*profile, *n)
+	}
+	if err != nil {
+		return err
+	}
+
+	db, closeDB, err := connectDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	result, err := seed.Apply(db, fixtures)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("users: %d created, %d already present; role permissions: %d created\n",
+		result.UsersCreated, result.UsersSkipped, result.PermissionsCreated)
+	return nil
+}
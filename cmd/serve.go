@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/auth"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/config"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/constants"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/database"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/features"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/health"
+	appLogger "github.com/sudo-hassan-zahid/go-api-server/internal/logger"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/mailer"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/metrics"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/middleware"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/repository"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/tracing"
+	"github.com/sudo-hassan-zahid/go-api-server/routes"
+	swagger "github.com/swaggo/fiber-swagger"
+)
+
+func runServe(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	db, closeDB, err := connectDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	// Refuse to serve against an outdated schema; local runs migrate themselves
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return fmt.Errorf("load migrations: %w", err)
+	}
+	if cfg.App.Environment == constants.ENV_LOCAL {
+		applied, err := migrator.Up(context.Background(), 0)
+		if err != nil {
+			return fmt.Errorf("migrate: %w", err)
+		}
+		for _, m := range applied {
+			appLogger.Log.Info().Int("version", m.Version).Str("name", m.Name).Msg("Applied migration")
+		}
+	}
+	if err := migrator.Check(context.Background()); err != nil {
+		return fmt.Errorf("refusing to start: %w", err)
+	}
+
+	// Tracing, flushed on shutdown
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.Tracing, cfg.App.Name, cfg.App.Environment)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			appLogger.Log.Error().Err(err).Msg("Error flushing traces")
+		}
+	}()
+	tracingEnabled := cfg.Tracing.Exporter != tracing.ExporterNone
+	if tracingEnabled {
+		if err := db.Use(tracing.GormPlugin{}); err != nil {
+			return fmt.Errorf("install tracing plugin: %w", err)
+		}
+	}
+
+	// Initialize Fiber App
+	app := fiber.New(fiber.Config{
+		AppName:      cfg.App.Name,
+		ReadTimeout:  20 * time.Second,
+		WriteTimeout: 20 * time.Second,
+	})
+
+	// Middlewares
+	app.Use(middleware.RequestID())
+	app.Use(middleware.ResolveClientIP(cfg.HTTP.TrustedProxies))
+	accessLog := middleware.NewAccessLog(cfg.Log.AccessSampleRate, cfg.Log.AccessExcludePaths)
+	app.Use(accessLog.Handler())
+	if tracingEnabled {
+		app.Use(tracing.Middleware())
+	}
+	if cfg.Metrics.Enabled {
+		app.Use(metrics.Middleware())
+	}
+	app.Use(recover.New())
+	app.Use(middleware.ErrorLogger())
+
+	// Request contexts end at DB_REQUEST_TIMEOUT, and when shutdown gives up
+	// waiting, so no query outlives its request
+	requestsCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+	app.Use(middleware.RequestContext(requestsCtx, cfg.DB.RequestTimeout))
+
+	// Runtime-safe settings, replaced on config reload
+	corsHandler := middleware.NewCORS(cfg.HTTP.CORSAllowOrigins)
+	rateLimiter := middleware.NewRateLimiter(cfg.HTTP.RateLimitMax, cfg.HTTP.RateLimitWindow)
+	features.Set(cfg.Features.Flags)
+	app.Use(corsHandler.Handler())
+	app.Use(rateLimiter.Handler())
+
+	watcher := config.NewWatcher(cfg, loadOptions)
+	watcher.Subscribe(func(next *config.Config) {
+		appLogger.SetLevel(next.Log.Level)
+		accessLog.Update(next.Log.AccessSampleRate, next.Log.AccessExcludePaths)
+		corsHandler.Update(next.HTTP.CORSAllowOrigins)
+		rateLimiter.Update(next.HTTP.RateLimitMax, next.HTTP.RateLimitWindow)
+		features.Set(next.Features.Flags)
+	})
+
+	// Auth init
+	if err := initAuth(cfg, db); err != nil {
+		return err
+	}
+	repository.SetCursorSecret(cfg.App.JWTSecret)
+
+	// Background jobs, stopped on shutdown
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go auth.RunRevocationCleanup(jobsCtx, auth.RevocationCleanupInterval)
+	go watcher.Run(jobsCtx, appLogger.Log)
+
+	// Mailer
+	mail, err := mailer.New(cfg.Mail)
+	if err != nil {
+		return fmt.Errorf("initialize mailer: %w", err)
+	}
+
+	// Readiness checks
+	checks := health.NewRegistry(cfg.Health.CheckTimeout)
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	checks.Register(health.Database(sqlDB))
+	if checker, ok := mail.(health.HealthChecker); ok {
+		checks.Register(checker)
+	}
+
+	// Metrics, on the API port unless a separate address is configured
+	var metricsServer *http.Server
+	if cfg.Metrics.Enabled {
+		if err := db.Use(metrics.GormPlugin{}); err != nil {
+			return fmt.Errorf("install metrics plugin: %w", err)
+		}
+		if err := metrics.RegisterDBStats(sqlDB, cfg.DB.Name); err != nil {
+			return fmt.Errorf("register db metrics: %w", err)
+		}
+		if cfg.Metrics.Addr == "" {
+			app.Get("/metrics", metrics.FiberHandler())
+		} else {
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", metrics.Handler())
+			metricsServer = &http.Server{Addr: cfg.Metrics.Addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+			go func() {
+				appLogger.Log.Info().Str("addr", cfg.Metrics.Addr).Msg("Starting metrics server")
+				if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					appLogger.Log.Error().Err(err).Msg("Metrics server failed")
+				}
+			}()
+		}
+	}
+
+	// Routes
+	routes.Setup(app, db, cfg, mail, checks)
+
+	// Swagger docs
+	app.Get("/swagger/*", swagger.FiberWrapHandler())
+
+	// Start Server in Goroutine
+	serverErrors := make(chan error, 1)
+	go func() {
+		appLogger.Log.Info().Str("port", cfg.App.Port).Msg("Starting Fiber server")
+		serverErrors <- app.Listen(":" + cfg.App.Port)
+	}()
+
+	// Graceful Shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case sig := <-quit:
+		appLogger.Log.Info().Str("signal", sig.String()).Msg("Shutting down server...")
+	case err := <-serverErrors:
+		return fmt.Errorf("server failed: %w", err)
+	}
+
+	// Fail readiness first so load balancers drain this instance
+	checks.SetShuttingDown()
+	if delay := cfg.Health.ShutdownDrainDelay; delay > 0 {
+		appLogger.Log.Info().Dur("delay", delay).Msg("Draining before shutdown")
+		time.Sleep(delay)
+	}
+
+	stopJobs()
+
+	if err := app.ShutdownWithTimeout(10 * time.Second); err != nil {
+		appLogger.Log.Error().Err(err).Msg("Error during server shutdown")
+	} else {
+		appLogger.Log.Info().Msg("Server gracefully stopped")
+	}
+	// Handlers still running past the timeout are abandoned; stop their
+	// queries before the pool is closed.
+	cancelRequests()
+	if metricsServer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := metricsServer.Shutdown(ctx); err != nil {
+			appLogger.Log.Error().Err(err).Msg("Error during metrics server shutdown")
+		}
+	}
+	return nil
+}
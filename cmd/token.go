@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/auth"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/config"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/models"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/repository"
+	"github.com/sudo-hassan-zahid/go-api-server/utils"
+)
+
+// runToken mints an access token for an existing account, signed with the
+// current key, so endpoints can be called without going through login.
+func runToken(cfg *config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "mint" {
+		return errors.New("usage: token mint (-email E | -user-id ID) [-ttl 15m]")
+	}
+
+	fs := flag.NewFlagSet("token mint", flag.ContinueOnError)
+	email := fs.String("email", "", "email of the account")
+	userID := fs.String("user-id", "", "ID of the account")
+	ttl := fs.Duration("ttl", auth.AccessTokenTTL, "token lifetime")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if (*email == "") == (*userID == "") {
+		fs.Usage()
+		return errors.New("exactly one of -email and -user-id is required")
+	}
+	if *ttl <= 0 || *ttl > 24*time.Hour {
+		return errors.New("-ttl must be between 0 and 24h")
+	}
+
+	db, closeDB, err := connectDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	if err := initAuth(cfg, db); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	users := repository.NewUserRepository(db)
+	var user *models.User
+	if *userID != "" {
+		id, err := uuid.Parse(*userID)
+		if err != nil {
+			return fmt.Errorf("invalid -user-id: %w", err)
+		}
+		user, err = users.GetByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("find user %s: %w", id, err)
+		}
+	} else {
+		user, err = users.GetByEmail(ctx, utils.SanitizeEmail(*email))
+		if err != nil {
+			return fmt.Errorf("find %s: %w", *email, err)
+		}
+	}
+
+	token, err := auth.GenerateJWT(user.ID.String(), user.Role, auth.TokenUseAccess, *ttl)
+	if err != nil {
+		return err
+	}
+	fmt.Println(token)
+	return nil
+}
@@ -1,71 +1,198 @@
 package auth
 
 import (
+	"context"
+	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/sudo-hassan-zahid/go-api-server/internal/config"
 	appError "github.com/sudo-hassan-zahid/go-api-server/internal/errors"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/metrics"
 )
 
 const (
-	AccessTokenTTL  = 15 * time.Minute
-	RefreshTokenTTL = 7 * 24 * time.Hour
+	AccessTokenTTL     = 15 * time.Minute
+	RefreshTokenTTL    = 7 * 24 * time.Hour
+	MFAPendingTokenTTL = 5 * time.Minute
 )
 
-var jwtSecret []byte
+// Values of the token_use claim. A token is only accepted where its use is
+// expected, so a refresh token cannot stand in for an access token. The
+// mfa_pending token is handed out after the password check of an account with
+// two-factor authentication and can only be exchanged at the MFA verify endpoint.
+const (
+	TokenUseAccess     = "access"
+	TokenUseRefresh    = "refresh"
+	TokenUseMFAPending = "mfa_pending"
+)
 
-func Init(cfg *config.Config) {
+var (
+	jwtSecret []byte
+	issuer    string
+	audience  []string
+	parser    *jwt.Parser
+)
+
+func Init(cfg *config.Config, store RevocationStore) error {
 	if len(cfg.App.JWTSecret) == 0 {
-		panic("JWT_SECRET is required")
+		return errors.New("JWT_SECRET is required")
 	}
+
+	set, err := loadKeys(cfg.Auth, cfg.App.JWTSecret)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Auth.RolePermissions != nil {
+		SetRolePermissions(cfg.Auth.RolePermissions)
+	} else {
+		SetRolePermissions(DefaultRolePermissions())
+	}
+
 	jwtSecret = cfg.App.JWTSecret
+	keys = set
+	revocations = store
+	issuer = cfg.Auth.JWTIssuer
+	audience = cfg.Auth.JWTAudience
+	parser = jwt.NewParser(
+		jwt.WithIssuer(issuer),
+		jwt.WithAudience(audience...),
+		jwt.WithLeeway(cfg.Auth.JWTLeeway),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
+	)
+	return nil
 }
 
+// Claims carries the user ID in the standard sub claim.
 type Claims struct {
-	UserID string `json:"user_id"`
-	Role   string `json:"role,omitempty"`
+	Role     string `json:"role,omitempty"`
+	FamilyID string `json:"fid,omitempty"`
+	TokenUse string `json:"token_use,omitempty"`
 	jwt.RegisteredClaims
 }
 
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+}
+
 func GenerateAccessToken(userID, role string) (string, error) {
-	return GenerateJWT(userID, role, AccessTokenTTL)
+	return GenerateJWT(userID, role, TokenUseAccess, AccessTokenTTL)
+}
+
+// GenerateRefreshToken issues a refresh token identified by tokenID (the jti)
+// and bound to the rotation family familyID.
+func GenerateRefreshToken(userID, tokenID, familyID string) (string, error) {
+	claims := newClaims(userID, "", TokenUseRefresh, RefreshTokenTTL)
+	claims.ID = tokenID
+	claims.FamilyID = familyID
+	return sign(claims)
 }
 
-func GenerateRefreshToken(userID string) (string, error) {
-	return GenerateJWT(userID, "", RefreshTokenTTL)
+func GenerateMFAPendingToken(userID string) (string, error) {
+	return GenerateJWT(userID, "", TokenUseMFAPending, MFAPendingTokenTTL)
 }
 
-func ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, appError.ErrTokenInvalid
-		}
-		return jwtSecret, nil
-	})
+// ValidateToken verifies the signature and the registered claims (iss, aud,
+// exp, nbf and iat, within the configured leeway), checks that the token is
+// meant for tokenUse and that it has not been revoked.
+func ValidateToken(ctx context.Context, tokenString, tokenUse string) (*Claims, error) {
+	token, err := parser.ParseWithClaims(tokenString, &Claims{}, keys.verificationKey)
 
 	if err != nil || !token.Valid {
-		return nil, appError.ErrTokenInvalid
+		return nil, reject(parseRejection(err))
 	}
 
 	claims, ok := token.Claims.(*Claims)
-	if !ok {
-		return nil, appError.ErrTokenInvalid
+	if !ok || claims.ID == "" || claims.Subject == "" || claims.IssuedAt == nil {
+		return nil, reject("claims")
+	}
+	if claims.TokenUse != tokenUse {
+		return nil, reject("wrong_use")
+	}
+
+	revoked, err := isRevoked(ctx, claims)
+	if err != nil {
+		return nil, reject("revocation_error")
+	}
+	if revoked {
+		return nil, reject("revoked")
 	}
 
 	return claims, nil
 }
 
-func GenerateJWT(userID, role string, ttl time.Duration) (string, error) {
-	claims := &Claims{
-		UserID: userID,
-		Role:   role,
+// rejection is the error ValidateToken returns. It matches ErrTokenInvalid
+// with errors.Is; the reason is only read for metrics, see RejectionReason.
+type rejection struct {
+	reason string
+}
+
+func (r *rejection) Error() string { return appError.ErrTokenInvalid.Error() }
+
+func (r *rejection) Unwrap() error { return appError.ErrTokenInvalid }
+
+func reject(reason string) error {
+	return &rejection{reason: reason}
+}
+
+// RejectionReason returns why ValidateToken refused a token, such as
+// "expired" or "revoked".
+func RejectionReason(err error) string {
+	var r *rejection
+	if errors.As(err, &r) {
+		return r.reason
+	}
+	return "other"
+}
+
+func parseRejection(err error) string {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return "expired"
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, jwt.ErrTokenUnverifiable):
+		return "signature"
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return "malformed"
+	default:
+		return "claims"
+	}
+}
+
+func GenerateJWT(userID, role, tokenUse string, ttl time.Duration) (string, error) {
+	return sign(newClaims(userID, role, tokenUse, ttl))
+}
+
+func newClaims(userID, role, tokenUse string, ttl time.Duration) *Claims {
+	now := time.Now()
+	return &Claims{
+		Role:     role,
+		TokenUse: tokenUse,
 		RegisteredClaims: jwt.RegisteredClaims{
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			ID:        uuid.NewString(),
+			Issuer:    issuer,
+			Subject:   userID,
+			Audience:  audience,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
 		},
 	}
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+// sign always uses the current key; its kid tells verifiers which key to use.
+func sign(claims *Claims) (string, error) {
+	token := jwt.NewWithClaims(keys.current.method, claims)
+	if keys.current.id != "" {
+		token.Header["kid"] = keys.current.id
+	}
+	signed, err := token.SignedString(keys.current.private)
+	if err != nil {
+		return "", err
+	}
+	metrics.TokensIssued.WithLabelValues(claims.TokenUse).Inc()
+	return signed, nil
 }
@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/config"
+	appError "github.com/sudo-hassan-zahid/go-api-server/internal/errors"
+)
+
+const (
+	testSecret   = "test-secret"
+	testIssuer   = "test-issuer"
+	testAudience = "test-audience"
+)
+
+// initTestAuth sets up HS256 signing with testSecret and no revocation store.
+func initTestAuth(t *testing.T, authCfg config.AuthConfig) {
+	t.Helper()
+
+	if authCfg.JWTIssuer == "" {
+		authCfg.JWTIssuer = testIssuer
+	}
+	if authCfg.JWTAudience == nil {
+		authCfg.JWTAudience = []string{testAudience}
+	}
+	if authCfg.JWTLeeway == 0 {
+		authCfg.JWTLeeway = 30 * time.Second
+	}
+	cfg := &config.Config{App: config.AppConfig{JWTSecret: []byte(testSecret)}, Auth: authCfg}
+	if err := Init(cfg, nil); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+}
+
+func TestValidateToken(t *testing.T) {
+	initTestAuth(t, config.AuthConfig{})
+
+	for _, tc := range []struct {
+		name   string
+		use    string
+		modify func(*Claims)
+		ok     bool
+	}{
+		{"access token", TokenUseAccess, nil, true},
+		{"refresh token", TokenUseRefresh, nil, false},
+		{"mfa_pending token", TokenUseMFAPending, nil, false},
+		{"no token_use", "", nil, false},
+		{"wrong issuer", TokenUseAccess, func(c *Claims) { c.Issuer = "someone-else" }, false},
+		{"no issuer", TokenUseAccess, func(c *Claims) { c.Issuer = "" }, false},
+		{"wrong audience", TokenUseAccess, func(c *Claims) { c.Audience = jwt.ClaimStrings{"other-api"} }, false},
+		{"no audience", TokenUseAccess, func(c *Claims) { c.Audience = nil }, false},
+		{"nbf in the future", TokenUseAccess, func(c *Claims) { c.NotBefore = jwt.NewNumericDate(time.Now().Add(time.Hour)) }, false},
+		{"nbf within leeway", TokenUseAccess, func(c *Claims) { c.NotBefore = jwt.NewNumericDate(time.Now().Add(10 * time.Second)) }, true},
+		{"expired", TokenUseAccess, func(c *Claims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour)) }, false},
+		{"no exp", TokenUseAccess, func(c *Claims) { c.ExpiresAt = nil }, false},
+		{"no jti", TokenUseAccess, func(c *Claims) { c.ID = "" }, false},
+	} {
+		claims := newClaims("user-1", "user", tc.use, AccessTokenTTL)
+		if tc.modify != nil {
+			tc.modify(claims)
+		}
+		token, err := sign(claims)
+		if err != nil {
+			t.Fatalf("%s: sign: %v", tc.name, err)
+		}
+
+		_, err = ValidateToken(context.Background(), token, TokenUseAccess)
+		if tc.ok && err != nil {
+			t.Errorf("%s: ValidateToken rejected it: %v", tc.name, err)
+		}
+		if !tc.ok && !errors.Is(err, appError.ErrTokenInvalid) {
+			t.Errorf("%s: ValidateToken = %v, want ErrTokenInvalid", tc.name, err)
+		}
+	}
+}
+
+func TestValidateTokenByUse(t *testing.T) {
+	initTestAuth(t, config.AuthConfig{})
+
+	refresh, err := GenerateRefreshToken("user-1", "token-1", "family-1")
+	if err != nil {
+		t.Fatalf("GenerateRefreshToken: %v", err)
+	}
+	if _, err := ValidateToken(context.Background(), refresh, TokenUseRefresh); err != nil {
+		t.Errorf("refresh token rejected as refresh: %v", err)
+	}
+
+	pending, err := GenerateMFAPendingToken("user-1")
+	if err != nil {
+		t.Fatalf("GenerateMFAPendingToken: %v", err)
+	}
+	if _, err := ValidateToken(context.Background(), pending, TokenUseMFAPending); err != nil {
+		t.Errorf("mfa_pending token rejected as mfa_pending: %v", err)
+	}
+	if _, err := ValidateToken(context.Background(), pending, TokenUseRefresh); err == nil {
+		t.Error("mfa_pending token accepted as refresh")
+	}
+}
+
+func TestValidateTokenWrongSecret(t *testing.T) {
+	initTestAuth(t, config.AuthConfig{})
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, newClaims("user-1", "user", TokenUseAccess, AccessTokenTTL)).
+		SignedString([]byte("another-secret"))
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if _, err := ValidateToken(context.Background(), token, TokenUseAccess); err == nil {
+		t.Error("ValidateToken accepted a token signed with another secret")
+	}
+}
+
+func TestRejectionReason(t *testing.T) {
+	initTestAuth(t, config.AuthConfig{})
+
+	expired := newClaims("user-1", "user", TokenUseAccess, AccessTokenTTL)
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+	expiredToken, err := sign(expired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refresh, err := GenerateRefreshToken("user-1", "token-1", "family-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		token, want string
+	}{
+		{expiredToken, "expired"},
+		{refresh, "wrong_use"},
+		{"not-a-jwt", "malformed"},
+	} {
+		_, err := ValidateToken(context.Background(), tc.token, TokenUseAccess)
+		if got := RejectionReason(err); got != tc.want {
+			t.Errorf("RejectionReason = %q, want %q", got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/config"
+)
+
+type signingKey struct {
+	id      string
+	method  jwt.SigningMethod
+	private crypto.PrivateKey
+	public  crypto.PublicKey
+}
+
+// keySet holds the key new tokens are signed with and every key, current or
+// retired, that tokens are still verified against, indexed by kid. legacy is
+// the HS256 secret, kept until legacyUntil to verify tokens without a kid
+// that were issued before the switch to JWT_KEYS.
+type keySet struct {
+	current     *signingKey
+	keys        map[string]*signingKey
+	order       []string
+	legacy      *signingKey
+	legacyUntil time.Time
+}
+
+var keys *keySet
+
+// loadKeys builds the key set from config. Without asymmetric keys tokens
+// are signed with HS256 and the shared JWT secret, as before.
+func loadKeys(cfg config.AuthConfig, secret []byte) (*keySet, error) {
+	if len(cfg.JWTKeys) == 0 {
+		hmacKey := &signingKey{method: jwt.SigningMethodHS256, private: secret, public: secret}
+		return &keySet{current: hmacKey}, nil
+	}
+
+	set := &keySet{keys: make(map[string]*signingKey, len(cfg.JWTKeys))}
+	if !cfg.JWTHS256Until.IsZero() {
+		set.legacy = &signingKey{method: jwt.SigningMethodHS256, public: secret}
+		set.legacyUntil = cfg.JWTHS256Until
+	}
+	for _, kc := range cfg.JWTKeys {
+		key, err := loadKeyFile(kc.ID, kc.Path)
+		if err != nil {
+			return nil, err
+		}
+		if _, dup := set.keys[kc.ID]; dup {
+			return nil, fmt.Errorf("JWT key %q is listed twice", kc.ID)
+		}
+		set.keys[kc.ID] = key
+		set.order = append(set.order, kc.ID)
+	}
+
+	current, ok := set.keys[cfg.JWTCurrentKeyID]
+	if !ok {
+		return nil, fmt.Errorf("JWT current key %q is not in JWT_KEYS", cfg.JWTCurrentKeyID)
+	}
+	if current.private == nil {
+		return nil, fmt.Errorf("JWT current key %q has no private key", cfg.JWTCurrentKeyID)
+	}
+	set.current = current
+
+	return set, nil
+}
+
+// verificationKey picks the key for an incoming token. The algorithm must
+// match the one bound to the key, so a token cannot pick its own algorithm.
+func (s *keySet) verificationKey(token *jwt.Token) (interface{}, error) {
+	if s.keys == nil {
+		if token.Method != jwt.SigningMethodHS256 {
+			return nil, errors.New("unexpected signing method")
+		}
+		return s.current.public, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := s.keys[kid]
+	if kid == "" && s.legacy != nil && time.Now().Before(s.legacyUntil) {
+		key, ok = s.legacy, true
+	}
+	if !ok {
+		return nil, errors.New("unknown key id")
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.public, nil
+}
+
+func loadKeyFile(id, path string) (*signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read JWT key %q: %w", id, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("JWT key %q: no PEM data in %s", id, path)
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("JWT key %q: unsupported PEM block %q", id, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse JWT key %q: %w", id, err)
+	}
+
+	key := &signingKey{id: id}
+	if signer, ok := parsed.(crypto.Signer); ok {
+		key.private = signer
+		key.public = signer.Public()
+	} else {
+		key.public = parsed
+	}
+
+	switch pub := key.public.(type) {
+	case *rsa.PublicKey:
+		key.method = jwt.SigningMethodRS256
+	case *ecdsa.PublicKey:
+		if pub.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("JWT key %q: only P-256 ECDSA keys are supported", id)
+		}
+		key.method = jwt.SigningMethodES256
+	case ed25519.PublicKey:
+		key.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("JWT key %q: unsupported key type %T", id, key.public)
+	}
+
+	return key, nil
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public verification keys (RFC 7517). It is empty when
+// tokens are signed with the shared HS256 secret.
+func JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	if keys == nil {
+		return set
+	}
+
+	for _, id := range keys.order {
+		key := keys.keys[id]
+		jwk := JWK{Kid: key.id, Use: "sig", Alg: key.method.Alg()}
+		switch pub := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = encodeSegment(pub.N.Bytes())
+			jwk.E = encodeSegment(big.NewInt(int64(pub.E)).Bytes())
+		case *ecdsa.PublicKey:
+			ecdhKey, err := pub.ECDH()
+			if err != nil {
+				continue
+			}
+			// Uncompressed point: 0x04 || X || Y
+			point := ecdhKey.Bytes()
+			size := (len(point) - 1) / 2
+			jwk.Kty = "EC"
+			jwk.Crv = "P-256"
+			jwk.X = encodeSegment(point[1 : 1+size])
+			jwk.Y = encodeSegment(point[1+size:])
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = encodeSegment(pub)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+func encodeSegment(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,335 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/config"
+)
+
+type testKeys struct {
+	rsa     *rsa.PrivateKey
+	ec      *ecdsa.PrivateKey
+	ed      ed25519.PrivateKey
+	retired *ecdsa.PrivateKey
+}
+
+func newTestKeys(t *testing.T) testKeys {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	retired, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testKeys{rsa: rsaKey, ec: ecKey, ed: edKey, retired: retired}
+}
+
+// writePEM writes one PEM block to dir/name and returns its path.
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func mustPKCS8(t *testing.T, key crypto.PrivateKey) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func mustPKIX(t *testing.T, key crypto.PublicKey) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+// writeTestKeys writes the keys in the PEM encodings loadKeyFile accepts and
+// returns the matching JWT_KEYS entries. The retired key is public only.
+func writeTestKeys(t *testing.T, k testKeys) []config.JWTKeyConfig {
+	t.Helper()
+
+	ecDER, err := x509.MarshalECPrivateKey(k.ec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	return []config.JWTKeyConfig{
+		{ID: "rsa", Path: writePEM(t, dir, "rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(k.rsa))},
+		{ID: "rsa-pkcs8", Path: writePEM(t, dir, "rsa8.pem", "PRIVATE KEY", mustPKCS8(t, k.rsa))},
+		{ID: "ec", Path: writePEM(t, dir, "ec.pem", "EC PRIVATE KEY", ecDER)},
+		{ID: "ed", Path: writePEM(t, dir, "ed.pem", "PRIVATE KEY", mustPKCS8(t, k.ed))},
+		{ID: "retired", Path: writePEM(t, dir, "retired.pem", "PUBLIC KEY", mustPKIX(t, k.retired.Public()))},
+	}
+}
+
+func TestLoadKeys(t *testing.T) {
+	k := newTestKeys(t)
+	entries := writeTestKeys(t, k)
+
+	set, err := loadKeys(config.AuthConfig{JWTKeys: entries, JWTCurrentKeyID: "ec"}, []byte(testSecret))
+	if err != nil {
+		t.Fatalf("loadKeys: %v", err)
+	}
+
+	for _, tc := range []struct {
+		id      string
+		alg     string
+		private bool
+	}{
+		{"rsa", "RS256", true},
+		{"rsa-pkcs8", "RS256", true},
+		{"ec", "ES256", true},
+		{"ed", "EdDSA", true},
+		{"retired", "ES256", false},
+	} {
+		key, ok := set.keys[tc.id]
+		if !ok {
+			t.Errorf("key %q not loaded", tc.id)
+			continue
+		}
+		if key.method.Alg() != tc.alg {
+			t.Errorf("key %q: alg %s, want %s", tc.id, key.method.Alg(), tc.alg)
+		}
+		if (key.private != nil) != tc.private {
+			t.Errorf("key %q: has private key = %v, want %v", tc.id, key.private != nil, tc.private)
+		}
+	}
+	if set.current.id != "ec" {
+		t.Errorf("current key %q, want ec", set.current.id)
+	}
+	if set.legacy != nil {
+		t.Error("legacy HS256 key loaded without JWT_HS256_UNTIL")
+	}
+}
+
+func TestLoadKeysErrors(t *testing.T) {
+	k := newTestKeys(t)
+	entries := writeTestKeys(t, k)
+	dir := t.TempDir()
+
+	plain := filepath.Join(dir, "plain.txt")
+	if err := os.WriteFile(plain, []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p384DER, err := x509.MarshalECPrivateKey(p384)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		keys    []config.JWTKeyConfig
+		current string
+	}{
+		{"current key not listed", entries, "missing"},
+		{"current key is public only", entries, "retired"},
+		{"duplicate kid", []config.JWTKeyConfig{entries[2], {ID: "ec", Path: entries[3].Path}}, "ec"},
+		{"missing file", []config.JWTKeyConfig{{ID: "ec", Path: filepath.Join(dir, "nope.pem")}}, "ec"},
+		{"not PEM", []config.JWTKeyConfig{{ID: "ec", Path: plain}}, "ec"},
+		{"P-384 curve", []config.JWTKeyConfig{{ID: "ec", Path: writePEM(t, dir, "p384.pem", "EC PRIVATE KEY", p384DER)}}, "ec"},
+		{"unsupported block", []config.JWTKeyConfig{{ID: "ec", Path: writePEM(t, dir, "cert.pem", "CERTIFICATE", []byte("x"))}}, "ec"},
+	} {
+		if _, err := loadKeys(config.AuthConfig{JWTKeys: tc.keys, JWTCurrentKeyID: tc.current}, []byte(testSecret)); err == nil {
+			t.Errorf("%s: loadKeys succeeded", tc.name)
+		}
+	}
+}
+
+func TestVerificationKey(t *testing.T) {
+	k := newTestKeys(t)
+	entries := writeTestKeys(t, k)
+
+	signed := func(method jwt.SigningMethod, kid string, key interface{}) string {
+		t.Helper()
+		token := jwt.NewWithClaims(method, jwt.RegisteredClaims{Subject: "user-1", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))})
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		s, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	legacy := signed(jwt.SigningMethodHS256, "", []byte(testSecret))
+
+	for _, tc := range []struct {
+		name  string
+		until time.Time
+		token string
+		ok    bool
+	}{
+		{"current key", time.Time{}, signed(jwt.SigningMethodES256, "ec", k.ec), true},
+		{"other listed key", time.Time{}, signed(jwt.SigningMethodRS256, "rsa", k.rsa), true},
+		{"EdDSA key", time.Time{}, signed(jwt.SigningMethodEdDSA, "ed", k.ed), true},
+		{"retired key", time.Time{}, signed(jwt.SigningMethodES256, "retired", k.retired), true},
+		{"unknown kid", time.Time{}, signed(jwt.SigningMethodES256, "unknown", k.ec), false},
+		{"kid of another key", time.Time{}, signed(jwt.SigningMethodES256, "retired", k.ec), false},
+		{"alg not bound to kid", time.Time{}, signed(jwt.SigningMethodHS256, "rsa", []byte(testSecret)), false},
+		{"HS256 without kid, no cut-off", time.Time{}, legacy, false},
+		{"HS256 without kid, before cut-off", time.Now().Add(time.Hour), legacy, true},
+		{"HS256 without kid, after cut-off", time.Now().Add(-time.Hour), legacy, false},
+		{"HS256 without kid, wrong secret", time.Now().Add(time.Hour), signed(jwt.SigningMethodHS256, "", []byte("another-secret")), false},
+	} {
+		set, err := loadKeys(config.AuthConfig{JWTKeys: entries, JWTCurrentKeyID: "ec", JWTHS256Until: tc.until}, []byte(testSecret))
+		if err != nil {
+			t.Fatalf("%s: loadKeys: %v", tc.name, err)
+		}
+		_, err = jwt.Parse(tc.token, set.verificationKey)
+		if tc.ok && err != nil {
+			t.Errorf("%s: rejected: %v", tc.name, err)
+		}
+		if !tc.ok && err == nil {
+			t.Errorf("%s: accepted", tc.name)
+		}
+	}
+}
+
+func TestVerificationKeyHS256Only(t *testing.T) {
+	set, err := loadKeys(config.AuthConfig{}, []byte(testSecret))
+	if err != nil {
+		t.Fatalf("loadKeys: %v", err)
+	}
+
+	hs := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{Subject: "user-1"})
+	token, err := hs.SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := jwt.Parse(token, set.verificationKey); err != nil {
+		t.Errorf("HS256 token rejected: %v", err)
+	}
+
+	// "none" and other algorithms must not be accepted in place of HS256.
+	none, err := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.RegisteredClaims{Subject: "user-1"}).
+		SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := jwt.Parse(none, set.verificationKey); err == nil {
+		t.Error("unsigned token accepted")
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	k := newTestKeys(t)
+	entries := writeTestKeys(t, k)
+
+	set, err := loadKeys(config.AuthConfig{JWTKeys: entries, JWTCurrentKeyID: "ec"}, []byte(testSecret))
+	if err != nil {
+		t.Fatalf("loadKeys: %v", err)
+	}
+	prev := keys
+	keys = set
+	t.Cleanup(func() { keys = prev })
+
+	jwks := JWKS()
+	if len(jwks.Keys) != len(entries) {
+		t.Fatalf("JWKS has %d keys, want %d", len(jwks.Keys), len(entries))
+	}
+	byKid := map[string]JWK{}
+	for i, jwk := range jwks.Keys {
+		if jwk.Kid != entries[i].ID {
+			t.Errorf("JWKS key %d is %q, want %q in JWT_KEYS order", i, jwk.Kid, entries[i].ID)
+		}
+		if jwk.Use != "sig" {
+			t.Errorf("key %q: use %q, want sig", jwk.Kid, jwk.Use)
+		}
+		byKid[jwk.Kid] = jwk
+	}
+
+	decode := func(s string) []byte {
+		t.Helper()
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			t.Fatalf("decode %q: %v", s, err)
+		}
+		return b
+	}
+
+	rsaJWK := byKid["rsa"]
+	if rsaJWK.Kty != "RSA" || rsaJWK.Alg != "RS256" {
+		t.Errorf("rsa: kty %s alg %s", rsaJWK.Kty, rsaJWK.Alg)
+	}
+	if n := new(big.Int).SetBytes(decode(rsaJWK.N)); n.Cmp(k.rsa.N) != 0 {
+		t.Error("rsa: n does not match the key")
+	}
+	if e := new(big.Int).SetBytes(decode(rsaJWK.E)); e.Int64() != int64(k.rsa.E) {
+		t.Errorf("rsa: e = %d, want %d", e.Int64(), k.rsa.E)
+	}
+
+	ecJWK := byKid["ec"]
+	if ecJWK.Kty != "EC" || ecJWK.Crv != "P-256" || ecJWK.Alg != "ES256" {
+		t.Errorf("ec: kty %s crv %s alg %s", ecJWK.Kty, ecJWK.Crv, ecJWK.Alg)
+	}
+	x, y := decode(ecJWK.X), decode(ecJWK.Y)
+	if len(x) != 32 || len(y) != 32 {
+		t.Errorf("ec: x and y are %d and %d bytes, want 32", len(x), len(y))
+	}
+	ecPub := ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	if !ecPub.Equal(k.ec.Public()) {
+		t.Error("ec: x and y do not match the key")
+	}
+
+	edJWK := byKid["ed"]
+	if edJWK.Kty != "OKP" || edJWK.Crv != "Ed25519" || edJWK.Alg != "EdDSA" {
+		t.Errorf("ed: kty %s crv %s alg %s", edJWK.Kty, edJWK.Crv, edJWK.Alg)
+	}
+	if !ed25519.PublicKey(decode(edJWK.X)).Equal(k.ed.Public()) {
+		t.Error("ed: x does not match the key")
+	}
+
+}
+
+func TestJWKSEmptyForHS256(t *testing.T) {
+	set, err := loadKeys(config.AuthConfig{}, []byte(testSecret))
+	if err != nil {
+		t.Fatalf("loadKeys: %v", err)
+	}
+	prev := keys
+	keys = set
+	t.Cleanup(func() { keys = prev })
+
+	if jwks := JWKS(); jwks.Keys == nil || len(jwks.Keys) != 0 {
+		t.Errorf("JWKS = %+v, want an empty key list", jwks)
+	}
+}
@@ -0,0 +1,28 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// NewOpaqueToken returns a random token to hand to the user together with
+// the hash to persist in its place.
+func NewOpaqueToken() (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, HashOpaqueToken(token), nil
+}
+
+// HashOpaqueToken signs the token with the JWT secret, so a leaked database
+// is not enough to forge or replay tokens.
+func HashOpaqueToken(token string) string {
+	mac := hmac.New(sha256.New, jwtSecret)
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
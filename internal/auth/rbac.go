@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/sudo-hassan-zahid/go-api-server/internal/constants"
+)
+
+// RolePermissionSource provides role to permission grants kept outside the
+// config, such as the role_permissions table.
+type RolePermissionSource interface {
+	ListRolePermissions(ctx context.Context) (map[string][]string, error)
+}
+
+var (
+	rolesMu         sync.RWMutex
+	rolePermissions = buildRoleTable(DefaultRolePermissions())
+)
+
+func DefaultRolePermissions() map[string][]string {
+	return map[string][]string{
+		constants.ROLE_ADMIN: {
+			constants.PERM_USERS_READ,
+			constants.PERM_USERS_WRITE,
+			constants.PERM_USERS_ADMIN,
+		},
+		constants.ROLE_USER: {},
+	}
+}
+
+// SetRolePermissions replaces the whole role to permission table.
+func SetRolePermissions(table map[string][]string) {
+	built := buildRoleTable(table)
+
+	rolesMu.Lock()
+	rolePermissions = built
+	rolesMu.Unlock()
+}
+
+// LoadRolePermissions overlays the grants from source on the current table.
+// A role found in source replaces that role's configured permissions.
+func LoadRolePermissions(ctx context.Context, source RolePermissionSource) error {
+	overrides, err := source.ListRolePermissions(ctx)
+	if err != nil {
+		return err
+	}
+	if len(overrides) == 0 {
+		return nil
+	}
+
+	rolesMu.Lock()
+	defer rolesMu.Unlock()
+	for role, perms := range buildRoleTable(overrides) {
+		rolePermissions[role] = perms
+	}
+	return nil
+}
+
+func HasPermission(role, permission string) bool {
+	rolesMu.RLock()
+	defer rolesMu.RUnlock()
+	return rolePermissions[role][permission]
+}
+
+// RoleExists reports whether role appears in the role table, even with no
+// permissions granted.
+func RoleExists(role string) bool {
+	rolesMu.RLock()
+	defer rolesMu.RUnlock()
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// Permissions returns the sorted permissions granted to role.
+func Permissions(role string) []string {
+	rolesMu.RLock()
+	defer rolesMu.RUnlock()
+
+	perms := make([]string, 0, len(rolePermissions[role]))
+	for perm := range rolePermissions[role] {
+		perms = append(perms, perm)
+	}
+	sort.Strings(perms)
+	return perms
+}
+
+func buildRoleTable(table map[string][]string) map[string]map[string]bool {
+	built := make(map[string]map[string]bool, len(table))
+	for role, perms := range table {
+		set := make(map[string]bool, len(perms))
+		for _, perm := range perms {
+			set[perm] = true
+		}
+		built[role] = set
+	}
+	return built
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/sudo-hassan-zahid/go-api-server/internal/logger"
+)
+
+const RevocationCleanupInterval = time.Hour
+
+// RevocationStore is consulted by ValidateToken for every token, so a token
+// revoked on logout is rejected right away instead of living until it expires.
+type RevocationStore interface {
+	IsRevoked(ctx context.Context, jti, userID string, issuedAt time.Time) (bool, error)
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+var revocations RevocationStore
+
+// RunRevocationCleanup drops revocation entries once the tokens they cover
+// would have expired anyway. It blocks until ctx is cancelled.
+func RunRevocationCleanup(ctx context.Context, interval time.Duration) {
+	if revocations == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			deleted, err := revocations.DeleteExpired(ctx, now)
+			if err != nil {
+				logger.Log.Error().Err(err).Msg("Failed to purge expired revocations")
+				continue
+			}
+			logger.Log.Debug().Int64("deleted", deleted).Msg("Purged expired revocations")
+		}
+	}
+}
+
+func isRevoked(ctx context.Context, claims *Claims) (bool, error) {
+	if revocations == nil {
+		return false, nil
+	}
+	return revocations.IsRevoked(ctx, claims.ID, claims.Subject, claims.IssuedAt.Time)
+}
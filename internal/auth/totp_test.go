@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA1 seed from RFC 6238 Appendix B.
+var rfc6238Secret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+// rfc6238Vectors are the SHA1 test vectors from RFC 6238 Appendix B. The RFC
+// lists 8 digit codes; a 6 digit code is their last six digits.
+var rfc6238Vectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestTOTPCode(t *testing.T) {
+	for _, tc := range rfc6238Vectors {
+		code, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(tc.unix, 0)))
+		if err != nil {
+			t.Fatalf("TOTPCode at %d: %v", tc.unix, err)
+		}
+		if code != tc.code {
+			t.Errorf("TOTPCode at %d = %s, want %s", tc.unix, code, tc.code)
+		}
+	}
+}
+
+func TestTOTPCodeInvalidSecret(t *testing.T) {
+	if _, err := TOTPCode("not base32!", 1); err == nil {
+		t.Error("TOTPCode accepted an invalid secret")
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	for _, tc := range rfc6238Vectors {
+		at := time.Unix(tc.unix, 0)
+		step, ok := ValidateTOTP(rfc6238Secret, tc.code, at)
+		if !ok {
+			t.Errorf("ValidateTOTP rejected %s at %d", tc.code, tc.unix)
+			continue
+		}
+		if want := TOTPStep(at); step != want {
+			t.Errorf("ValidateTOTP at %d matched step %d, want %d", tc.unix, step, want)
+		}
+	}
+}
+
+func TestValidateTOTPSkew(t *testing.T) {
+	at := time.Unix(1111111111, 0)
+	code := "050471"
+
+	for _, tc := range []struct {
+		name   string
+		offset time.Duration
+		ok     bool
+	}{
+		{"previous step", -TOTPPeriod * time.Second, true},
+		{"next step", TOTPPeriod * time.Second, true},
+		{"two steps early", -2 * TOTPPeriod * time.Second, false},
+		{"two steps late", 2 * TOTPPeriod * time.Second, false},
+	} {
+		if _, ok := ValidateTOTP(rfc6238Secret, code, at.Add(tc.offset)); ok != tc.ok {
+			t.Errorf("%s: ValidateTOTP = %v, want %v", tc.name, ok, tc.ok)
+		}
+	}
+}
+
+func TestValidateTOTPRejectsMalformedCodes(t *testing.T) {
+	at := time.Unix(1111111111, 0)
+	for _, code := range []string{"", "05047", "0504710", "14050471", "abcdef"} {
+		if _, ok := ValidateTOTP(rfc6238Secret, code, at); ok {
+			t.Errorf("ValidateTOTP accepted %q", code)
+		}
+	}
+}
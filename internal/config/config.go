@@ -1,103 +1,434 @@
 package config
 
 import (
-	"os"
+	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
+
+	"github.com/sudo-hassan-zahid/go-api-server/internal/constants"
 )
 
 type AppConfig struct {
-	Name        string
-	Environment string
-	Port        string
-	JWTSecret   []byte
+	Name        string `env:"APP_NAME"`
+	Environment string `env:"APP_ENVIRONMENT"`
+	Port        string `env:"APP_PORT"`
+	JWTSecret   []byte `env:"JWT_SECRET" secret:"true"`
+	// ConfigWatchInterval polls the config file for changes; 0 turns it off.
+	ConfigWatchInterval time.Duration `env:"CONFIG_WATCH_INTERVAL"`
 }
 
 type DBConfig struct {
-	Host            string
-	Port            string
-	User            string
-	Password        string
-	Name            string
-	SSLMode         string
-	MaxOpenConns    int
-	MaxIdleConns    int
-	ConnMaxLifetime time.Duration
+	Host            string        `env:"DB_HOST"`
+	Port            string        `env:"DB_PORT"`
+	User            string        `env:"DB_USER"`
+	Password        string        `env:"DB_PASSWORD" secret:"true"`
+	Name            string        `env:"DB_NAME"`
+	SSLMode         string        `env:"DB_SSLMODE"`
+	MaxOpenConns    int           `env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `env:"DB_CONN_MAX_LIFETIME"`
+
+	// StatementTimeout is the Postgres statement_timeout of every connection
+	// and RequestTimeout the deadline for all queries of one HTTP request.
+	// Zero turns either off.
+	StatementTimeout time.Duration `env:"DB_STATEMENT_TIMEOUT"`
+	RequestTimeout   time.Duration `env:"DB_REQUEST_TIMEOUT"`
 }
 
+// LogConfig also shapes the access log: AccessSampleRate is the share of 2xx
+// responses logged, and requests to AccessExcludePaths are never logged.
 type LogConfig struct {
-	Level string
+	Level              string   `env:"LOG_LEVEL" reload:"true"`
+	AccessSampleRate   float64  `env:"ACCESS_LOG_SAMPLE_RATE" reload:"true"`
+	AccessExcludePaths []string `env:"ACCESS_LOG_EXCLUDE_PATHS" reload:"true"`
+}
+
+// HTTPConfig holds request handling settings. A RateLimitMax of 0 turns rate
+// limiting off. The client IP is read from X-Forwarded-For only when the
+// connection comes from one of TrustedProxies (IPs or CIDR ranges).
+type HTTPConfig struct {
+	CORSAllowOrigins []string      `env:"CORS_ALLOW_ORIGINS" reload:"true"`
+	RateLimitMax     int           `env:"RATE_LIMIT_MAX" reload:"true"`
+	RateLimitWindow  time.Duration `env:"RATE_LIMIT_WINDOW" reload:"true"`
+	TrustedProxies   []string      `env:"TRUSTED_PROXIES"`
+}
+
+// HealthConfig bounds the readiness checks. On shutdown the readiness probe
+// fails for ShutdownDrainDelay before the server stops accepting requests.
+type HealthConfig struct {
+	CheckTimeout       time.Duration `env:"HEALTH_CHECK_TIMEOUT"`
+	ShutdownDrainDelay time.Duration `env:"SHUTDOWN_DRAIN_DELAY"`
+}
+
+// MetricsConfig controls the Prometheus endpoint. With an empty Addr the
+// metrics are served at /metrics on the API port, otherwise on a separate
+// listener so they need not be exposed publicly.
+type MetricsConfig struct {
+	Enabled bool   `env:"METRICS_ENABLED"`
+	Addr    string `env:"METRICS_ADDR"`
+}
+
+// TracingConfig selects the span exporter: none, stdout or otlp. Endpoint is
+// the OTLP/HTTP collector URL. SampleRatio applies to new traces only; a
+// sampled incoming traceparent is always followed.
+type TracingConfig struct {
+	Exporter    string  `env:"TRACING_EXPORTER"`
+	Endpoint    string  `env:"TRACING_OTLP_ENDPOINT"`
+	SampleRatio float64 `env:"TRACING_SAMPLE_RATIO"`
+}
+
+type FeatureConfig struct {
+	Flags map[string]bool `env:"FEATURE_FLAGS" reload:"true"`
+}
+
+type JWTKeyConfig struct {
+	ID   string
+	Path string
+}
+
+type AuthConfig struct {
+	JWTKeys              []JWTKeyConfig      `env:"JWT_KEYS"`
+	JWTCurrentKeyID      string              `env:"JWT_CURRENT_KEY_ID"`
+	JWTHS256Until        time.Time           `env:"JWT_HS256_UNTIL"`
+	JWTIssuer            string              `env:"JWT_ISSUER"`
+	JWTAudience          []string            `env:"JWT_AUDIENCE"`
+	JWTLeeway            time.Duration       `env:"JWT_LEEWAY"`
+	RolePermissions      map[string][]string `env:"AUTH_ROLE_PERMISSIONS"`
+	RequireVerifiedEmail bool                `env:"AUTH_REQUIRE_VERIFIED_EMAIL"`
+	VerificationTokenTTL time.Duration       `env:"AUTH_VERIFICATION_TOKEN_TTL"`
+	ResetTokenTTL        time.Duration       `env:"AUTH_RESET_TOKEN_TTL"`
+}
+
+type MailConfig struct {
+	Driver   string `env:"MAIL_DRIVER"`
+	Host     string `env:"SMTP_HOST"`
+	Port     string `env:"SMTP_PORT"`
+	Username string `env:"SMTP_USERNAME"`
+	Password string `env:"SMTP_PASSWORD" secret:"true"`
+	From     string `env:"MAIL_FROM"`
 }
 
 type Config struct {
-	App AppConfig
-	DB  DBConfig
-	Log LogConfig
+	App      AppConfig
+	DB       DBConfig
+	Log      LogConfig
+	Auth     AuthConfig
+	Mail     MailConfig
+	HTTP     HTTPConfig
+	Health   HealthConfig
+	Metrics  MetricsConfig
+	Tracing  TracingConfig
+	Features FeatureConfig
+
+	// file is the config file that was loaded, if any, and sources maps
+	// each env key to the layer its value came from.
+	file    string
+	sources map[string]string
 }
 
+// Load reads the config with default options, see LoadWithOptions.
 func Load() (*Config, error) {
+	return LoadWithOptions(Options{})
+}
+
+// LoadWithOptions builds the config from layers, each overriding the one
+// before: built-in defaults, the config file for the environment, env vars
+// (and .env when present, with KEY_FILE read in place of an unset KEY), then
+// the overrides in opts. All problems are collected and returned together as
+// a *ValidationError.
+func LoadWithOptions(opts Options) (*Config, error) {
 	_ = godotenv.Load()
 
+	env, err := newReader(opts)
+	if err != nil {
+		return nil, &ValidationError{Problems: []string{err.Error()}}
+	}
+	environment := env.get("APP_ENVIRONMENT", constants.ENV_LOCAL)
+
+	// The log mail driver writes one-time tokens to the log, so only local
+	// runs use it unless told otherwise.
+	mailDriver := "smtp"
+	if environment == constants.ENV_LOCAL {
+		mailDriver = "log"
+	}
+
 	cfg := &Config{
 		App: AppConfig{
-			Name:        getEnv("APP_NAME", "go_api_server"),
-			Environment: getEnv("APP_ENVIRONMENT", "local"),
-			Port:        getEnv("APP_PORT", "8080"),
-			JWTSecret:   []byte(mustGetEnv("JWT_SECRET")),
+			Name:        env.get("APP_NAME", "go_api_server"),
+			Environment: environment,
+			Port:        env.get("APP_PORT", "8080"),
+			JWTSecret:   []byte(env.required("JWT_SECRET")),
+
+			ConfigWatchInterval: env.duration("CONFIG_WATCH_INTERVAL", 0),
 		},
 		DB: DBConfig{
-			Host:            getEnv("DB_HOST", "localhost"),
-			Port:            getEnv("DB_PORT", "5432"),
-			User:            getEnv("DB_USER", "postgres"),
-			Password:        getEnv("DB_PASSWORD", ""),
-			Name:            getEnv("DB_NAME", "go_api_server"),
-			SSLMode:         getEnv("DB_SSLMODE", "disable"),
-			MaxOpenConns:    getEnvAsInt("DB_MAX_OPEN_CONNS", 25),
-			MaxIdleConns:    getEnvAsInt("DB_MAX_IDLE_CONNS", 25),
-			ConnMaxLifetime: getEnvAsDuration("DB_CONN_MAX_LIFETIME", 5*time.Minute),
+			Host:            env.get("DB_HOST", "localhost"),
+			Port:            env.get("DB_PORT", "5432"),
+			User:            env.get("DB_USER", "postgres"),
+			Password:        env.get("DB_PASSWORD", ""),
+			Name:            env.get("DB_NAME", "go_api_server"),
+			SSLMode:         env.get("DB_SSLMODE", "disable"),
+			MaxOpenConns:    env.int("DB_MAX_OPEN_CONNS", 25),
+			MaxIdleConns:    env.int("DB_MAX_IDLE_CONNS", 25),
+			ConnMaxLifetime: env.duration("DB_CONN_MAX_LIFETIME", 5*time.Minute),
+
+			StatementTimeout: env.duration("DB_STATEMENT_TIMEOUT", 30*time.Second),
+			RequestTimeout:   env.duration("DB_REQUEST_TIMEOUT", 10*time.Second),
 		},
 		Log: LogConfig{
-			Level: getEnv("LOG_LEVEL", "debug"),
+			Level:              env.get("LOG_LEVEL", "debug"),
+			AccessSampleRate:   env.float("ACCESS_LOG_SAMPLE_RATE", 1),
+			AccessExcludePaths: env.list("ACCESS_LOG_EXCLUDE_PATHS", []string{"/livez", "/readyz", "/metrics"}),
+		},
+		Auth: AuthConfig{
+			JWTKeys:              env.jwtKeys("JWT_KEYS"),
+			JWTCurrentKeyID:      env.get("JWT_CURRENT_KEY_ID", ""),
+			JWTHS256Until:        env.timestamp("JWT_HS256_UNTIL"),
+			JWTIssuer:            env.get("JWT_ISSUER", "go_api_server"),
+			JWTAudience:          env.list("JWT_AUDIENCE", []string{"go_api_server"}),
+			JWTLeeway:            env.duration("JWT_LEEWAY", 30*time.Second),
+			RolePermissions:      env.rolePermissions("AUTH_ROLE_PERMISSIONS"),
+			RequireVerifiedEmail: env.bool("AUTH_REQUIRE_VERIFIED_EMAIL", false),
+			VerificationTokenTTL: env.duration("AUTH_VERIFICATION_TOKEN_TTL", 24*time.Hour),
+			ResetTokenTTL:        env.duration("AUTH_RESET_TOKEN_TTL", 30*time.Minute),
+		},
+		Mail: MailConfig{
+			Driver:   env.get("MAIL_DRIVER", mailDriver),
+			Host:     env.get("SMTP_HOST", "localhost"),
+			Port:     env.get("SMTP_PORT", "587"),
+			Username: env.get("SMTP_USERNAME", ""),
+			Password: env.get("SMTP_PASSWORD", ""),
+			From:     env.get("MAIL_FROM", "no-reply@localhost"),
+		},
+		HTTP: HTTPConfig{
+			CORSAllowOrigins: env.list("CORS_ALLOW_ORIGINS", []string{"*"}),
+			RateLimitMax:     env.int("RATE_LIMIT_MAX", 0),
+			RateLimitWindow:  env.duration("RATE_LIMIT_WINDOW", time.Minute),
+			TrustedProxies:   env.list("TRUSTED_PROXIES", nil),
+		},
+		Health: HealthConfig{
+			CheckTimeout:       env.duration("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+			ShutdownDrainDelay: env.duration("SHUTDOWN_DRAIN_DELAY", 0),
+		},
+		Metrics: MetricsConfig{
+			Enabled: env.bool("METRICS_ENABLED", false),
+			Addr:    env.get("METRICS_ADDR", ""),
+		},
+		Tracing: TracingConfig{
+			Exporter:    env.get("TRACING_EXPORTER", "none"),
+			Endpoint:    env.get("TRACING_OTLP_ENDPOINT", "http://localhost:4318"),
+			SampleRatio: env.float("TRACING_SAMPLE_RATIO", 1),
+		},
+		Features: FeatureConfig{
+			Flags: env.flags("FEATURE_FLAGS"),
 		},
 	}
 
+	cfg.file = env.file
+	cfg.sources = env.sources
+	problems := append(env.problems, cfg.validate()...)
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
 	return cfg, nil
 }
 
-func getEnv(key, defaultVal string) string {
-	if val := os.Getenv(key); val != "" {
+// reader reads typed values from the merged layers. A value that is missing
+// or malformed is recorded as a problem and the default is used, so every
+// problem can be reported at once.
+type reader struct {
+	file     string
+	values   map[string]setting
+	sources  map[string]string
+	problems []string
+}
+
+// lookup returns the raw value of key and remembers where it came from.
+func (e *reader) lookup(key string) string {
+	v, ok := e.values[key]
+	if !ok || v.value == "" {
+		e.sources[key] = SourceDefault
+		return ""
+	}
+	e.sources[key] = v.source
+	return v.value
+}
+
+func (e *reader) fail(key, format string, args ...interface{}) {
+	problem := key + ": " + fmt.Sprintf(format, args...)
+	if src := e.sources[key]; src != SourceEnv && src != SourceDefault {
+		problem += " (from " + src + ")"
+	}
+	e.problems = append(e.problems, problem)
+}
+
+func (e *reader) get(key, defaultVal string) string {
+	if val := e.lookup(key); val != "" {
 		return val
 	}
 	return defaultVal
 }
 
-func mustGetEnv(key string) string {
-	if val := os.Getenv(key); val != "" {
-		return val
+func (e *reader) required(key string) string {
+	val := e.lookup(key)
+	if val == "" {
+		e.fail(key, "is required")
 	}
-	panic("missing required env: " + key)
+	return val
 }
 
-func getEnvAsInt(key string, defaultVal int) int {
-	if val := os.Getenv(key); val != "" {
+func (e *reader) int(key string, defaultVal int) int {
+	if val := e.lookup(key); val != "" {
 		i, err := strconv.Atoi(val)
 		if err != nil {
-			panic("invalid int for " + key)
+			e.fail(key, "%q is not an integer", val)
+			return defaultVal
 		}
 		return i
 	}
 	return defaultVal
 }
 
-func getEnvAsDuration(key string, defaultVal time.Duration) time.Duration {
-	if val := os.Getenv(key); val != "" {
+func (e *reader) float(key string, defaultVal float64) float64 {
+	if val := e.lookup(key); val != "" {
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			e.fail(key, "%q is not a number", val)
+			return defaultVal
+		}
+		return f
+	}
+	return defaultVal
+}
+
+func (e *reader) duration(key string, defaultVal time.Duration) time.Duration {
+	if val := e.lookup(key); val != "" {
 		d, err := time.ParseDuration(val)
 		if err != nil {
-			panic("invalid duration for " + key)
+			e.fail(key, "%q is not a duration such as 30s or 5m", val)
+			return defaultVal
 		}
 		return d
 	}
 	return defaultVal
 }
+
+// timestamp parses an RFC 3339 time such as 2025-01-31T00:00:00Z. An unset
+// key gives the zero time.
+func (e *reader) timestamp(key string) time.Time {
+	val := e.lookup(key)
+	if val == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		e.fail(key, "%q is not an RFC 3339 time such as 2025-01-31T00:00:00Z", val)
+	}
+	return t
+}
+
+func (e *reader) bool(key string, defaultVal bool) bool {
+	if val := e.lookup(key); val != "" {
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			e.fail(key, "%q is not a boolean", val)
+			return defaultVal
+		}
+		return b
+	}
+	return defaultVal
+}
+
+// list parses a comma separated list, dropping empty entries.
+func (e *reader) list(key string, defaultVal []string) []string {
+	val := e.lookup(key)
+	if val == "" {
+		return defaultVal
+	}
+
+	var list []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// jwtKeys parses a comma separated list of kid=path/to/key.pem pairs.
+func (e *reader) jwtKeys(key string) []JWTKeyConfig {
+	val := e.lookup(key)
+	if val == "" {
+		return nil
+	}
+
+	var keys []JWTKeyConfig
+	for _, entry := range strings.Split(val, ",") {
+		id, path, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || id == "" || path == "" {
+			e.fail(key, "entry %q is not kid=path", entry)
+			continue
+		}
+		keys = append(keys, JWTKeyConfig{ID: id, Path: path})
+	}
+	return keys
+}
+
+// rolePermissions parses role=perm,perm entries separated by semicolons,
+// e.g. "admin=users:read,users:write;support=users:read".
+// It returns nil when the variable is unset so the built-in table applies.
+func (e *reader) rolePermissions(key string) map[string][]string {
+	val := e.lookup(key)
+	if val == "" {
+		return nil
+	}
+
+	table := make(map[string][]string)
+	for _, entry := range strings.Split(val, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		role, perms, ok := strings.Cut(entry, "=")
+		role = strings.TrimSpace(role)
+		if !ok || role == "" {
+			e.fail(key, "entry %q is not role=perm,perm", entry)
+			continue
+		}
+
+		table[role] = []string{}
+		for _, perm := range strings.Split(perms, ",") {
+			if perm = strings.TrimSpace(perm); perm != "" {
+				table[role] = append(table[role], perm)
+			}
+		}
+	}
+	return table
+}
+
+// flags parses feature flags as a comma separated list of name or
+// name=bool entries; a bare name turns the flag on.
+func (e *reader) flags(key string) map[string]bool {
+	flags := map[string]bool{}
+	for _, entry := range e.list(key, nil) {
+		name, val, hasVal := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			e.fail(key, "entry %q has no flag name", entry)
+			continue
+		}
+		on := true
+		if hasVal {
+			b, err := strconv.ParseBool(strings.TrimSpace(val))
+			if err != nil {
+				e.fail(key, "flag %s: %q is not a boolean", name, val)
+				continue
+			}
+			on = b
+		}
+		flags[name] = on
+	}
+	return flags
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// clearEnv unsets every config key, its _FILE variant and the config file
+// selectors for the duration of the test.
+func clearEnv(t *testing.T) {
+	t.Helper()
+
+	for key := range knownKeys() {
+		t.Setenv(key, "")
+		t.Setenv(key+"_FILE", "")
+	}
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("CONFIG_DIR", "")
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadWithOptionsLayers(t *testing.T) {
+	clearEnv(t)
+	dir := t.TempDir()
+
+	writeFile(t, dir, "local.yaml", `
+app:
+  port: "9000"
+db:
+  host: file-host
+  user: file-user
+  name: file-name
+  password: file-password
+  max_open_conns: 40
+log:
+  level: warn
+`)
+	secrets := t.TempDir()
+	t.Setenv("JWT_SECRET", "env-secret")
+	t.Setenv("DB_USER", "env-user")
+	t.Setenv("DB_NAME", "env-name")
+	t.Setenv("DB_NAME_FILE", writeFile(t, secrets, "db_name", "secret-name\n"))
+	t.Setenv("DB_PASSWORD_FILE", writeFile(t, secrets, "db_password", "secret-password\n"))
+	t.Setenv("LOG_LEVEL", "error")
+
+	cfg, err := LoadWithOptions(Options{
+		Dir:       dir,
+		Overrides: map[string]string{"LOG_LEVEL": "info", "DB_MAX_OPEN_CONNS": "60"},
+	})
+	if err != nil {
+		t.Fatalf("LoadWithOptions: %v", err)
+	}
+
+	file := SourceFile + " " + filepath.Join(dir, "local.yaml")
+	for _, tc := range []struct {
+		name   string
+		key    string
+		got    interface{}
+		want   interface{}
+		source string
+	}{
+		{"default", "DB_PORT", cfg.DB.Port, "5432", SourceDefault},
+		{"file over default", "APP_PORT", cfg.App.Port, "9000", file},
+		{"file over default", "DB_HOST", cfg.DB.Host, "file-host", file},
+		{"env over file", "DB_USER", cfg.DB.User, "env-user", SourceEnv},
+		{"KEY_FILE over file", "DB_PASSWORD", cfg.DB.Password, "secret-password", "env DB_PASSWORD_FILE=" + filepath.Join(secrets, "db_password")},
+		{"KEY over KEY_FILE", "DB_NAME", cfg.DB.Name, "env-name", SourceEnv},
+		{"flag over env", "LOG_LEVEL", cfg.Log.Level, "info", SourceFlag},
+		{"flag over file", "DB_MAX_OPEN_CONNS", cfg.DB.MaxOpenConns, 60, SourceFlag},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("%s: %s = %v, want %v", tc.name, tc.key, tc.got, tc.want)
+		}
+		if src := cfg.Source(tc.key); src != tc.source {
+			t.Errorf("%s: %s came from %q, want %q", tc.name, tc.key, src, tc.source)
+		}
+	}
+}
+
+func TestLoadWithOptionsEnvironmentPicksFile(t *testing.T) {
+	clearEnv(t)
+	dir := t.TempDir()
+	writeFile(t, dir, "local.yaml", "app:\n  port: \"9000\"\n")
+	writeFile(t, dir, "dev.toml", "[app]\nport = \"9100\"\n")
+	t.Setenv("JWT_SECRET", "env-secret")
+
+	cfg, err := LoadWithOptions(Options{Dir: dir, Overrides: map[string]string{"APP_ENVIRONMENT": "dev"}})
+	if err != nil {
+		t.Fatalf("LoadWithOptions: %v", err)
+	}
+	if cfg.App.Port != "9100" {
+		t.Errorf("APP_PORT = %s, want 9100 from dev.toml", cfg.App.Port)
+	}
+}
+
+func TestLoadWithOptionsCollectsProblems(t *testing.T) {
+	clearEnv(t)
+	dir := t.TempDir()
+	writeFile(t, dir, "local.yaml", "rate_limit:\n  window: soon\n")
+
+	t.Setenv("DB_MAX_OPEN_CONNS", "many")
+	t.Setenv("ACCESS_LOG_SAMPLE_RATE", "2")
+	t.Setenv("METRICS_ENABLED", "maybe")
+	t.Setenv("JWT_HS256_UNTIL", "tomorrow")
+	t.Setenv("JWT_KEYS", "k1="+filepath.Join(dir, "k1.pem"))
+
+	_, err := LoadWithOptions(Options{Dir: dir})
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("LoadWithOptions = %v, want a *ValidationError", err)
+	}
+
+	for _, want := range []string{
+		"JWT_SECRET:",
+		"DB_MAX_OPEN_CONNS:",
+		"ACCESS_LOG_SAMPLE_RATE:",
+		"METRICS_ENABLED:",
+		"JWT_HS256_UNTIL:",
+		"JWT_CURRENT_KEY_ID:",
+		"RATE_LIMIT_WINDOW: \"soon\" is not a duration such as 30s or 5m (from file " + filepath.Join(dir, "local.yaml") + ")",
+	} {
+		found := false
+		for _, problem := range verr.Problems {
+			if strings.HasPrefix(problem, want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("no problem starting with %q in:\n%s", want, verr)
+		}
+	}
+}
+
+func TestLoadWithOptionsRejectsFileErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{"unknown key", "local.yaml", "db:\n  hots: typo\n"},
+		{"other environment", "local.yaml", "app:\n  environment: prod\n"},
+		{"nested list", "local.yaml", "cors:\n  allow_origins: [[a]]\n"},
+		{"bad syntax", "local.toml", "[app\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			clearEnv(t)
+			dir := t.TempDir()
+			writeFile(t, dir, tc.file, tc.content)
+			t.Setenv("JWT_SECRET", "env-secret")
+
+			var verr *ValidationError
+			if _, err := LoadWithOptions(Options{Dir: dir}); !errors.As(err, &verr) {
+				t.Errorf("LoadWithOptions = %v, want a *ValidationError", err)
+			}
+		})
+	}
+}
+
+func TestLoadWithOptionsMissingFile(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("JWT_SECRET", "env-secret")
+
+	if _, err := LoadWithOptions(Options{File: filepath.Join(t.TempDir(), "missing.yaml")}); err == nil {
+		t.Error("LoadWithOptions accepted a named config file that does not exist")
+	}
+}
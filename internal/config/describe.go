@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+const redacted = "[REDACTED]"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Field is one leaf value of the config, addressed by its dotted path such
+// as "DB.MaxOpenConns".
+type Field struct {
+	Path   string
+	Key    string
+	Value  string
+	Source string
+	Secret bool
+	Reload bool
+
+	// raw is Value before redaction, for comparing two loads.
+	raw string
+}
+
+// Fields flattens the config for display. Values of fields tagged
+// `secret:"true"` are replaced unless they are empty.
+func (c *Config) Fields() []Field {
+	var fields []Field
+	describe(reflect.ValueOf(*c), "", &fields)
+	for i := range fields {
+		fields[i].Source = c.Source(fields[i].Key)
+	}
+	return fields
+}
+
+func describe(v reflect.Value, prefix string, fields *[]Field) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf, fv := t.Field(i), v.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		path := prefix + sf.Name
+
+		if fv.Kind() == reflect.Struct && fv.Type() != timeType {
+			describe(fv, path+".", fields)
+			continue
+		}
+
+		field := Field{
+			Path:   path,
+			Key:    sf.Tag.Get("env"),
+			Value:  formatValue(fv),
+			Secret: sf.Tag.Get("secret") == "true",
+			Reload: sf.Tag.Get("reload") == "true",
+		}
+		field.raw = field.Value
+		if field.Secret && field.Value != "" {
+			field.Value = redacted
+		}
+		*fields = append(*fields, field)
+	}
+}
+
+func formatValue(v reflect.Value) string {
+	if v.Type() == timeType {
+		if t := v.Interface().(time.Time); !t.IsZero() {
+			return t.Format(time.RFC3339)
+		}
+		return ""
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes())
+		}
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = formatValue(v.Index(i))
+		}
+		return strings.Join(items, ",")
+	case reflect.Map:
+		entries := make([]string, 0, v.Len())
+		for _, key := range v.MapKeys() {
+			entries = append(entries, fmt.Sprintf("%v=%s", key.Interface(), formatValue(v.MapIndex(key))))
+		}
+		sort.Strings(entries)
+		return strings.Join(entries, ";")
+	case reflect.Struct:
+		parts := make([]string, v.NumField())
+		for i := range parts {
+			parts[i] = formatValue(v.Field(i))
+		}
+		return strings.Join(parts, "=")
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
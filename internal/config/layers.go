@@ -0,0 +1,242 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Sources reported for each value. File based sources carry the path, e.g.
+// "file config/prod.yaml" or "env JWT_SECRET_FILE=/run/secrets/jwt".
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// DefaultConfigDir holds one optional file per environment, named after it:
+// local.yaml, dev.toml, prod.yml and so on.
+const DefaultConfigDir = "config"
+
+var configExtensions = []string{".yaml", ".yml", ".toml"}
+
+// Options selects the config file and carries overrides from the command
+// line. Overrides are keyed by env name, e.g. "APP_PORT".
+type Options struct {
+	File      string
+	Dir       string
+	Overrides map[string]string
+}
+
+// BindFlags registers the config flags on fs. They apply on top of every
+// other layer.
+func BindFlags(fs *flag.FlagSet) *Options {
+	opts := &Options{Overrides: map[string]string{}}
+	fs.StringVar(&opts.File, "config", "", "config file (default $CONFIG_FILE or <config dir>/<environment>.yaml|toml)")
+	fs.StringVar(&opts.Dir, "config-dir", "", "directory of per-environment config files (default $CONFIG_DIR or "+DefaultConfigDir+")")
+	override := func(name, key, usage string) {
+		fs.Func(name, usage+" (sets "+key+")", func(v string) error {
+			opts.Overrides[key] = v
+			return nil
+		})
+	}
+	override("env", "APP_ENVIRONMENT", "environment: local, dev or prod")
+	override("port", "APP_PORT", "HTTP port")
+	override("log-level", "LOG_LEVEL", "log level")
+	fs.Func("set", "set any value as KEY=VALUE, e.g. -set DB_MAX_OPEN_CONNS=50 (repeatable)", func(v string) error {
+		key, val, ok := strings.Cut(v, "=")
+		if !ok || !knownKeys()[key] {
+			return fmt.Errorf("expected KEY=VALUE with a known key, got %q", v)
+		}
+		opts.Overrides[key] = val
+		return nil
+	})
+	return opts
+}
+
+// Source reports where the value of an env key came from.
+func (c *Config) Source(key string) string {
+	if src, ok := c.sources[key]; ok {
+		return src
+	}
+	return SourceDefault
+}
+
+type setting struct {
+	value  string
+	source string
+}
+
+func newReader(opts Options) (*reader, error) {
+	keys := knownKeys()
+	values := map[string]setting{}
+
+	// The environment picks the file, so it is resolved from env and flags first.
+	environment := os.Getenv("APP_ENVIRONMENT")
+	if v, ok := opts.Overrides["APP_ENVIRONMENT"]; ok {
+		environment = v
+	}
+	if environment == "" {
+		environment = "local"
+	}
+
+	path, err := configFile(opts, environment)
+	if err != nil {
+		return nil, err
+	}
+	if path != "" {
+		fileValues, err := readConfigFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for key, val := range fileValues {
+			if !keys[key] {
+				return nil, fmt.Errorf("%s: unknown setting %s", path, fileKey(key))
+			}
+			if key == "APP_ENVIRONMENT" && val != environment {
+				return nil, fmt.Errorf("%s: sets environment %q but was loaded for %q", path, val, environment)
+			}
+			values[key] = setting{value: val, source: SourceFile + " " + path}
+		}
+	}
+
+	for key := range keys {
+		if val := os.Getenv(key); val != "" {
+			values[key] = setting{value: val, source: SourceEnv}
+			continue
+		}
+		if secretPath := os.Getenv(key + "_FILE"); secretPath != "" {
+			data, err := os.ReadFile(secretPath)
+			if err != nil {
+				return nil, fmt.Errorf("%s_FILE: %w", key, err)
+			}
+			values[key] = setting{
+				value:  strings.TrimRight(string(data), "\r\n"),
+				source: fmt.Sprintf("%s %s_FILE=%s", SourceEnv, key, secretPath),
+			}
+		}
+	}
+
+	for key, val := range opts.Overrides {
+		values[key] = setting{value: val, source: SourceFlag}
+	}
+
+	return &reader{file: path, values: values, sources: map[string]string{}}, nil
+}
+
+// configFile returns the file to load, or "" when there is none. An
+// explicitly named file must exist; the per-environment file is optional.
+func configFile(opts Options, environment string) (string, error) {
+	file := opts.File
+	if file == "" {
+		file = os.Getenv("CONFIG_FILE")
+	}
+	if file != "" {
+		if _, err := os.Stat(file); err != nil {
+			return "", fmt.Errorf("config file: %w", err)
+		}
+		return file, nil
+	}
+
+	dir := opts.Dir
+	if dir == "" {
+		dir = os.Getenv("CONFIG_DIR")
+	}
+	if dir == "" {
+		dir = DefaultConfigDir
+	}
+	for _, ext := range configExtensions {
+		path := filepath.Join(dir, environment+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", nil
+}
+
+// readConfigFile flattens a YAML or TOML document into env keys: nested keys
+// are joined with underscores and upper-cased, so db.max_open_conns sets
+// DB_MAX_OPEN_CONNS. Lists are joined with commas.
+func readConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		err = toml.Unmarshal(data, &doc)
+	default:
+		err = yaml.Unmarshal(data, &doc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	if err := flatten("", doc, values); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return values, nil
+}
+
+func flatten(prefix string, node interface{}, out map[string]string) error {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			key := strings.ToUpper(k)
+			if prefix != "" {
+				key = prefix + "_" + key
+			}
+			if err := flatten(key, child, out); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			switch item.(type) {
+			case map[string]interface{}, []interface{}:
+				return fmt.Errorf("%s: list items must be plain values", fileKey(prefix))
+			}
+			items[i] = fmt.Sprint(item)
+		}
+		out[prefix] = strings.Join(items, ",")
+	case nil:
+		out[prefix] = ""
+	default:
+		out[prefix] = fmt.Sprint(v)
+	}
+	return nil
+}
+
+// fileKey shows an env key the way it is written in a config file.
+func fileKey(key string) string {
+	return strings.ToLower(strings.Replace(key, "_", ".", 1))
+}
+
+// knownKeys collects the env tags of the config structs.
+func knownKeys() map[string]bool {
+	keys := map[string]bool{}
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if key := f.Tag.Get("env"); key != "" {
+				keys[key] = true
+			} else if f.Type.Kind() == reflect.Struct {
+				walk(f.Type)
+			}
+		}
+	}
+	walk(reflect.TypeOf(Config{}))
+	return keys
+}
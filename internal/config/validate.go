@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/sudo-hassan-zahid/go-api-server/internal/constants"
+)
+
+// MinProdJWTSecretLength is the shortest JWT_SECRET accepted in prod. The
+// secret also keys token hashes and pagination cursors.
+const MinProdJWTSecretLength = 32
+
+// ValidationError lists every problem found while loading the config.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	b.WriteString("invalid configuration:")
+	for _, p := range e.Problems {
+		b.WriteString("\n  - ")
+		b.WriteString(p)
+	}
+	return b.String()
+}
+
+// validate runs the checks that span more than one value.
+func (c *Config) validate() []string {
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	env := c.App.Environment
+	check(env == constants.ENV_LOCAL || env == constants.ENV_DEV || env == constants.ENV_PROD,
+		"APP_ENVIRONMENT: %q must be one of %s, %s or %s", env, constants.ENV_LOCAL, constants.ENV_DEV, constants.ENV_PROD)
+
+	check(c.DB.MaxOpenConns > 0, "DB_MAX_OPEN_CONNS: must be positive, got %d", c.DB.MaxOpenConns)
+	check(c.DB.MaxIdleConns >= 0, "DB_MAX_IDLE_CONNS: must not be negative, got %d", c.DB.MaxIdleConns)
+	check(c.DB.MaxIdleConns <= c.DB.MaxOpenConns,
+		"DB_MAX_IDLE_CONNS: %d is more than DB_MAX_OPEN_CONNS (%d)", c.DB.MaxIdleConns, c.DB.MaxOpenConns)
+
+	check(c.DB.StatementTimeout >= 0, "DB_STATEMENT_TIMEOUT: must not be negative, got %s", c.DB.StatementTimeout)
+	check(c.DB.RequestTimeout >= 0, "DB_REQUEST_TIMEOUT: must not be negative, got %s", c.DB.RequestTimeout)
+
+	if env == constants.ENV_PROD {
+		check(len(c.App.JWTSecret) == 0 || len(c.App.JWTSecret) >= MinProdJWTSecretLength,
+			"JWT_SECRET: must be at least %d characters in prod", MinProdJWTSecretLength)
+		check(c.DB.SSLMode != "disable", "DB_SSLMODE: must not be disable in prod")
+	}
+
+	check(c.Log.AccessSampleRate >= 0 && c.Log.AccessSampleRate <= 1,
+		"ACCESS_LOG_SAMPLE_RATE: must be between 0 and 1, got %g", c.Log.AccessSampleRate)
+	for _, proxy := range c.HTTP.TrustedProxies {
+		_, _, cidrErr := net.ParseCIDR(proxy)
+		check(net.ParseIP(proxy) != nil || cidrErr == nil, "TRUSTED_PROXIES: %q is not an IP address or CIDR range", proxy)
+	}
+	check(c.HTTP.RateLimitMax >= 0, "RATE_LIMIT_MAX: must not be negative, got %d", c.HTTP.RateLimitMax)
+	check(c.HTTP.RateLimitWindow > 0, "RATE_LIMIT_WINDOW: must be positive, got %s", c.HTTP.RateLimitWindow)
+	check(c.Health.CheckTimeout > 0, "HEALTH_CHECK_TIMEOUT: must be positive, got %s", c.Health.CheckTimeout)
+	check(c.Health.ShutdownDrainDelay >= 0, "SHUTDOWN_DRAIN_DELAY: must not be negative, got %s", c.Health.ShutdownDrainDelay)
+	if c.Metrics.Addr != "" {
+		_, _, err := net.SplitHostPort(c.Metrics.Addr)
+		check(err == nil, "METRICS_ADDR: %q must be host:port", c.Metrics.Addr)
+	}
+	switch c.Mail.Driver {
+	case "log":
+		// The log driver writes verification and reset tokens to the log.
+		check(env == constants.ENV_LOCAL, "MAIL_DRIVER: log is only allowed in %s, use smtp", constants.ENV_LOCAL)
+	case "smtp":
+	default:
+		problems = append(problems, fmt.Sprintf("MAIL_DRIVER: %q must be log or smtp", c.Mail.Driver))
+	}
+	switch c.Tracing.Exporter {
+	case "none", "stdout", "otlp":
+	default:
+		problems = append(problems, fmt.Sprintf("TRACING_EXPORTER: %q must be one of none, stdout or otlp", c.Tracing.Exporter))
+	}
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1,
+		"TRACING_SAMPLE_RATIO: must be between 0 and 1, got %g", c.Tracing.SampleRatio)
+	check(c.App.ConfigWatchInterval >= 0, "CONFIG_WATCH_INTERVAL: must not be negative, got %s", c.App.ConfigWatchInterval)
+
+	check(len(c.Auth.JWTKeys) == 0 || c.Auth.JWTCurrentKeyID != "", "JWT_CURRENT_KEY_ID: is required when JWT_KEYS is set")
+	if c.Auth.JWTCurrentKeyID != "" {
+		found := false
+		for _, k := range c.Auth.JWTKeys {
+			found = found || k.ID == c.Auth.JWTCurrentKeyID
+		}
+		check(found, "JWT_CURRENT_KEY_ID: %q is not listed in JWT_KEYS", c.Auth.JWTCurrentKeyID)
+	}
+	return problems
+}
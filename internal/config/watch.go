@@ -0,0 +1,170 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Change is one value that differs between two loads. Loads are compared on
+// the real values, but secret values are redacted here.
+type Change struct {
+	Key string
+	Old string
+	New string
+}
+
+// Watcher re-reads the config on SIGHUP and, when ConfigWatchInterval is set,
+// whenever the config file changes. Only fields tagged `reload:"true"` are
+// applied at runtime; other changes are rejected until the next restart.
+type Watcher struct {
+	opts    Options
+	current atomic.Pointer[Config]
+
+	mu          sync.Mutex
+	subscribers []func(*Config)
+}
+
+func NewWatcher(cfg *Config, opts Options) *Watcher {
+	w := &Watcher{opts: opts}
+	w.current.Store(cfg)
+	return w
+}
+
+// Current returns the config as of the last applied reload.
+func (w *Watcher) Current() *Config {
+	return w.current.Load()
+}
+
+// Subscribe registers fn to be called with the new config after every reload
+// that changed a runtime-safe value.
+func (w *Watcher) Subscribe(fn func(*Config)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// Reload loads the config again and applies the runtime-safe changes. An
+// invalid config is not applied at all.
+func (w *Watcher) Reload() (applied, rejected []Change, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	loaded, err := LoadWithOptions(w.opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	old := w.current.Load()
+	oldFields := map[string]Field{}
+	for _, f := range old.Fields() {
+		oldFields[f.Key] = f
+	}
+	for _, f := range loaded.Fields() {
+		before := oldFields[f.Key]
+		if before.raw == f.raw {
+			continue
+		}
+		change := Change{Key: f.Key, Old: before.Value, New: f.Value}
+		if f.Reload {
+			applied = append(applied, change)
+		} else {
+			rejected = append(rejected, change)
+		}
+	}
+	if len(applied) == 0 {
+		return nil, rejected, nil
+	}
+
+	next := *old
+	copyReloadable(reflect.ValueOf(&next).Elem(), reflect.ValueOf(loaded).Elem())
+	next.sources = make(map[string]string, len(old.sources))
+	for key, src := range old.sources {
+		next.sources[key] = src
+	}
+	for _, c := range applied {
+		next.sources[c.Key] = loaded.Source(c.Key)
+	}
+	w.current.Store(&next)
+
+	for _, fn := range w.subscribers {
+		fn(&next)
+	}
+	return applied, rejected, nil
+}
+
+func copyReloadable(dst, src reflect.Value) {
+	t := dst.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		switch {
+		case !f.IsExported():
+		case f.Tag.Get("reload") == "true":
+			dst.Field(i).Set(src.Field(i))
+		case f.Type.Kind() == reflect.Struct:
+			copyReloadable(dst.Field(i), src.Field(i))
+		}
+	}
+}
+
+// Run reloads on SIGHUP and on config file changes until ctx is done.
+func (w *Watcher) Run(ctx context.Context, log zerolog.Logger) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var poll <-chan time.Time
+	file, interval := w.Current().file, w.Current().App.ConfigWatchInterval
+	modTime := fileModTime(file)
+	if file != "" && interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			log.Info().Msg("SIGHUP received, reloading config")
+		case <-poll:
+			mt := fileModTime(file)
+			if mt.Equal(modTime) {
+				continue
+			}
+			modTime = mt
+			log.Info().Str("file", file).Msg("Config file changed, reloading config")
+		}
+
+		applied, rejected, err := w.Reload()
+		if err != nil {
+			log.Error().Err(err).Msg("Config reload failed, keeping the current config")
+			continue
+		}
+		for _, c := range applied {
+			log.Info().Str("key", c.Key).Str("old", c.Old).Str("new", c.New).Msg("Config change applied")
+		}
+		for _, c := range rejected {
+			log.Warn().Str("key", c.Key).Str("old", c.Old).Str("new", c.New).Msg("Config change needs a restart, not applied")
+		}
+	}
+}
+
+func fileModTime(path string) time.Time {
+	if path == "" {
+		return time.Time{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -5,3 +5,14 @@ const (
 	ENV_DEV   = "dev"
 	ENV_PROD  = "prod"
 )
+
+const (
+	ROLE_USER  = "user"
+	ROLE_ADMIN = "admin"
+)
+
+const (
+	PERM_USERS_READ  = "users:read"
+	PERM_USERS_WRITE = "users:write"
+	PERM_USERS_ADMIN = "users:admin"
+)
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/sudo-hassan-zahid/go-api-server/internal/logger"
+)
+
+const slowQueryThreshold = 200 * time.Millisecond
+
+// gormLogger writes GORM's output through the logger in the query's context,
+// so SQL lines carry the request ID of the request that ran them.
+type gormLogger struct {
+	level gormlogger.LogLevel
+}
+
+func newGormLogger(level gormlogger.LogLevel) gormlogger.Interface {
+	return gormLogger{level: level}
+}
+
+func (l gormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	l.level = level
+	return l
+}
+
+func (l gormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Info {
+		logger.FromContext(ctx).Info().Msgf(msg, data...)
+	}
+}
+
+func (l gormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		logger.FromContext(ctx).Warn().Msgf(msg, data...)
+	}
+}
+
+func (l gormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Error {
+		logger.FromContext(ctx).Error().Msgf(msg, data...)
+	}
+}
+
+// Trace logs failed queries at Error, slow ones at Warn and, in Info mode,
+// every query. A missing record is an expected outcome, not an error.
+func (l gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	log := logger.FromContext(ctx)
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= gormlogger.Error:
+		sql, rows := fc()
+		log.Error().Err(err).Str("sql", sql).Int64("rows", rows).Dur("elapsed", elapsed).Msg("Query failed")
+	case elapsed > slowQueryThreshold && l.level >= gormlogger.Warn:
+		sql, rows := fc()
+		log.Warn().Str("sql", sql).Int64("rows", rows).Dur("elapsed", elapsed).Msg("Slow query")
+	case l.level >= gormlogger.Info:
+		sql, rows := fc()
+		log.Debug().Str("sql", sql).Int64("rows", rows).Dur("elapsed", elapsed).Msg("Query")
+	}
+}
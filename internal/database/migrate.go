@@ -0,0 +1,335 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// MigrationsDir is where `migrate create` writes new files, relative to the
+// repository root. They are embedded into the binary at build time.
+const MigrationsDir = "internal/database/migrations"
+
+// migrationLockID keys the Postgres advisory lock held while migrating, so
+// instances started by concurrent deploys apply migrations one at a time.
+const migrationLockID = 72_616_001
+
+var (
+	ErrSchemaBehind   = errors.New("database schema is behind, run `migrate up`")
+	ErrUnknownVersion = errors.New("database schema is newer than this binary")
+
+	migrationFileRegex = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+	migrationNameRegex = regexp.MustCompile(`^\w+$`)
+)
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	embedded, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := loadMigrations(embedded)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: sqlDB, migrations: migrations}, nil
+}
+
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, file := range files {
+		m := migrationFileRegex.FindStringSubmatch(file)
+		if m == nil {
+			return nil, fmt.Errorf("migration %s: name must look like 0001_name.up.sql", file)
+		}
+		version, _ := strconv.Atoi(m[1])
+		body, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = migration
+		} else if migration.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, migration.Name, m[2])
+		}
+		if m[3] == "up" {
+			migration.Up = string(body)
+		} else {
+			migration.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up applies up to steps pending migrations, or all of them when steps <= 0.
+// Each migration runs in its own transaction.
+func (m *Migrator) Up(ctx context.Context, steps int) ([]Migration, error) {
+	var applied []Migration
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if steps > 0 && len(applied) == steps {
+				break
+			}
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+			err := inTx(ctx, conn, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx,
+					`INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, now())`,
+					migration.Version, migration.Name)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back the last steps applied migrations, newest first.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+			}
+			err := inTx(ctx, conn, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status lists every known migration with the time it was applied, if it was.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := ensureMigrationsTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	done, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, len(m.migrations))
+	for i, migration := range m.migrations {
+		status[i] = MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if at, ok := done[migration.Version]; ok {
+			status[i].AppliedAt = &at
+		}
+	}
+	return status, nil
+}
+
+// Check returns ErrSchemaBehind when migrations are pending and
+// ErrUnknownVersion when the database has migrations this binary lacks.
+func (m *Migrator) Check(ctx context.Context) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := ensureMigrationsTable(ctx, conn); err != nil {
+		return err
+	}
+	done, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	known := map[int]bool{}
+	for _, migration := range m.migrations {
+		known[migration.Version] = true
+		if _, ok := done[migration.Version]; !ok {
+			return fmt.Errorf("%w: %d_%s is pending", ErrSchemaBehind, migration.Version, migration.Name)
+		}
+	}
+	for version := range done {
+		if !known[version] {
+			return fmt.Errorf("%w: version %d is applied", ErrUnknownVersion, version)
+		}
+	}
+	return nil
+}
+
+// CreateMigration writes an empty up/down pair numbered after the newest
+// migration in dir and returns their paths.
+func CreateMigration(dir, name string) (string, string, error) {
+	name = strings.ToLower(strings.Join(strings.Fields(name), "_"))
+	if !migrationNameRegex.MatchString(name) {
+		return "", "", fmt.Errorf("invalid migration name %q", name)
+	}
+
+	migrations, err := loadMigrations(os.DirFS(dir))
+	if err != nil {
+		return "", "", err
+	}
+	next := 1
+	if len(migrations) > 0 {
+		next = migrations[len(migrations)-1].Version + 1
+	}
+
+	base := filepath.Join(dir, fmt.Sprintf("%04d_%s", next, name))
+	up, down := base+".up.sql", base+".down.sql"
+	for _, path := range []string{up, down} {
+		if err := os.WriteFile(path, []byte("-- "+filepath.Base(path)+"\n"), 0o644); err != nil {
+			return "", "", err
+		}
+	}
+	return up, down, nil
+}
+
+// locked runs fn on a single connection holding the migration advisory lock.
+// The lock is session scoped, so it has to be taken and released on the same
+// connection. DB_STATEMENT_TIMEOUT is lifted for the session: waiting for the
+// lock and rewriting large tables can both take longer than any query should.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SET statement_timeout = 0`); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), `RESET statement_timeout`)
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID)
+
+	if err := ensureMigrationsTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func ensureMigrationsTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version bigint PRIMARY KEY,
+		name text NOT NULL,
+		applied_at timestamptz NOT NULL
+	)`)
+	return err
+}
+
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	done := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		done[version] = at
+	}
+	return done, rows.Err()
+}
+
+func inTx(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
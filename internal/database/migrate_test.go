@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/sudo-hassan-zahid/go-api-server/internal/models"
+)
+
+// baselineUser is the users table as AutoMigrate created it before the
+// schema was managed by migrations.
+type baselineUser struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Email      string    `gorm:"uniqueIndex;not null"`
+	Password   string    `gorm:"not null"`
+	FirstName  string    `gorm:"index:idx_name;not null"`
+	LastName   string    `gorm:"index:idx_name;not null"`
+	IsVerified bool      `gorm:"default:false"`
+	Role       string    `gorm:"default:'user'"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	DeletedAt  gorm.DeletedAt `gorm:"index"`
+}
+
+func (baselineUser) TableName() string { return "users" }
+
+// openTestDB connects to TEST_DATABASE_DSN and confines the test to a fresh
+// schema, which is dropped afterwards. The pool holds a single connection so
+// the search_path set here applies to every query.
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	schema := fmt.Sprintf("migrate_test_%d", time.Now().UnixNano())
+	if err := db.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() { db.Exec("DROP SCHEMA " + schema + " CASCADE") })
+	if err := db.Exec("SET search_path TO " + schema + ", public").Error; err != nil {
+		t.Fatalf("set search_path: %v", err)
+	}
+	return db
+}
+
+func TestMigrateUpgradesAutoMigratedSchema(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	if err := db.AutoMigrate(&baselineUser{}); err != nil {
+		t.Fatalf("baseline schema: %v", err)
+	}
+	existing := baselineUser{ID: uuid.New(), Email: "old@example.com", Password: "x", FirstName: "Old", LastName: "User"}
+	if err := db.Create(&existing).Error; err != nil {
+		t.Fatalf("baseline user: %v", err)
+	}
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	if _, err := migrator.Up(ctx, 0); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if err := migrator.Check(ctx); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+
+	user := &models.User{
+		Email:       "new@example.com",
+		Password:    "password123",
+		FirstName:   "New",
+		LastName:    "User",
+		TOTPSecret:  "JBSWY3DPEHPK3PXP",
+		TOTPEnabled: true,
+	}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("create user on migrated schema: %v", err)
+	}
+
+	var old models.User
+	if err := db.First(&old, "id = ?", existing.ID).Error; err != nil {
+		t.Fatalf("read baseline user: %v", err)
+	}
+	if old.TOTPEnabled || old.TOTPLastStep != 0 {
+		t.Errorf("baseline user got TOTP defaults enabled=%v last_step=%d", old.TOTPEnabled, old.TOTPLastStep)
+	}
+
+	if _, err := migrator.Down(ctx, len(migrator.migrations)); err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+}
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS recovery_codes;
+DROP TABLE IF EXISTS one_time_tokens;
+DROP TABLE IF EXISTS user_token_revocations;
+DROP TABLE IF EXISTS revoked_tokens;
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,83 @@
+CREATE TABLE IF NOT EXISTS users (
+    id uuid PRIMARY KEY,
+    email text NOT NULL,
+    password text NOT NULL,
+    first_name text NOT NULL,
+    last_name text NOT NULL,
+    is_verified boolean DEFAULT false,
+    role text DEFAULT 'user',
+    totp_secret text,
+    totp_enabled boolean DEFAULT false,
+    totp_last_step bigint DEFAULT 0,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+CREATE INDEX IF NOT EXISTS idx_name ON users (first_name, last_name);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+-- Databases created by AutoMigrate before migrations existed already have a
+-- users table, which CREATE TABLE IF NOT EXISTS leaves as it is. Add the
+-- columns introduced since then.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret text;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled boolean DEFAULT false;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_step bigint DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id uuid PRIMARY KEY,
+    user_id uuid NOT NULL,
+    family_id uuid NOT NULL,
+    expires_at timestamptz NOT NULL,
+    used_at timestamptz,
+    revoked_at timestamptz,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti text PRIMARY KEY,
+    user_id uuid NOT NULL,
+    expires_at timestamptz NOT NULL,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_user_id ON revoked_tokens (user_id);
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+
+CREATE TABLE IF NOT EXISTS user_token_revocations (
+    user_id uuid PRIMARY KEY,
+    revoked_before timestamptz NOT NULL,
+    expires_at timestamptz NOT NULL,
+    updated_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_user_token_revocations_expires_at ON user_token_revocations (expires_at);
+
+CREATE TABLE IF NOT EXISTS one_time_tokens (
+    id uuid PRIMARY KEY,
+    user_id uuid NOT NULL,
+    purpose text NOT NULL,
+    token_hash text NOT NULL,
+    expires_at timestamptz NOT NULL,
+    used_at timestamptz,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_one_time_tokens_user_id ON one_time_tokens (user_id);
+CREATE INDEX IF NOT EXISTS idx_one_time_tokens_purpose ON one_time_tokens (purpose);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_one_time_tokens_token_hash ON one_time_tokens (token_hash);
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id uuid PRIMARY KEY,
+    user_id uuid NOT NULL,
+    code_hash text NOT NULL,
+    used_at timestamptz,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_id ON recovery_codes (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_recovery_codes_code_hash ON recovery_codes (code_hash);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role text NOT NULL,
+    permission text NOT NULL,
+    PRIMARY KEY (role, permission)
+);
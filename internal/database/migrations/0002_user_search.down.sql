@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_users_full_name_trgm;
+DROP INDEX IF EXISTS idx_users_email_trgm;
+DROP INDEX IF EXISTS idx_users_search_vector;
+ALTER TABLE users DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS mfa_attempts (
+    jti text PRIMARY KEY,
+    user_id uuid NOT NULL,
+    failures integer NOT NULL DEFAULT 0,
+    expires_at timestamptz NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_mfa_attempts_user_id ON mfa_attempts (user_id);
+CREATE INDEX IF NOT EXISTS idx_mfa_attempts_expires_at ON mfa_attempts (expires_at);
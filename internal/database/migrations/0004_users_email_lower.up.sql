@@ -0,0 +1,3 @@
+-- Emails are matched case-insensitively, both exactly and by prefix, see
+-- UserRepository.GetByEmail and List.
+CREATE INDEX IF NOT EXISTS idx_users_email_lower ON users (lower(email) text_pattern_ops);
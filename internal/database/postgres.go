@@ -2,14 +2,24 @@ package database
 
 import (
 	"fmt"
+	"strconv"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgconn/ctxwatch"
+	"github.com/jackc/pgx/v5/stdlib"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
+	gormlogger "gorm.io/gorm/logger"
 
 	"github.com/sudo-hassan-zahid/go-api-server/internal/config"
 )
 
+// cancelDeadlineDelay is how long a cancelled query gets to honour the cancel
+// request before its connection is closed.
+const cancelDeadlineDelay = 2 * time.Second
+
 func Connect(cfg config.DBConfig, isLocal bool) (*gorm.DB, error) {
 	dsn := fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
@@ -21,13 +31,27 @@ func Connect(cfg config.DBConfig, isLocal bool) (*gorm.DB, error) {
 		cfg.SSLMode,
 	)
 
-	logLevel := logger.Error
+	connConfig, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.StatementTimeout > 0 {
+		connConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10)
+	}
+	// By default pgx only breaks the connection when a context is cancelled
+	// and Postgres keeps running the query. Sending a cancel request stops it
+	// on the server as well.
+	connConfig.BuildContextWatcherHandler = func(conn *pgconn.PgConn) ctxwatch.Handler {
+		return &pgconn.CancelRequestContextWatcherHandler{Conn: conn, DeadlineDelay: cancelDeadlineDelay}
+	}
+
+	logLevel := gormlogger.Error
 	if isLocal {
-		logLevel = logger.Info
+		logLevel = gormlogger.Info
 	}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logLevel),
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: stdlib.OpenDB(*connConfig)}), &gorm.Config{
+		Logger: newGormLogger(logLevel),
 	})
 	if err != nil {
 		return nil, err
@@ -1,9 +1,32 @@
 package dto
 
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error     string `json:"error"`
+	RequestID string `json:"request_id,omitempty"`
 }
 
 type SuccessResponse struct {
 	Message string `json:"message"`
 }
+
+// PageQuery holds the pagination parameters shared by every list endpoint:
+// page/page_size for offset pages, or the next_cursor of a previous page.
+type PageQuery struct {
+	Page     int    `query:"page" validate:"omitempty,min=1"`
+	PageSize int    `query:"page_size" validate:"omitempty,min=1,max=100"`
+	Cursor   string `query:"cursor"`
+}
+
+type PageLinks struct {
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// PageMeta is embedded next to the data of every paginated list response.
+type PageMeta struct {
+	Total      int64     `json:"total"`
+	Page       int       `json:"page,omitempty"`
+	PageSize   int       `json:"page_size"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+	Links      PageLinks `json:"links"`
+}
@@ -1,5 +1,7 @@
 package dto
 
+import "github.com/sudo-hassan-zahid/go-api-server/internal/models"
+
 type CreateUserRequest struct {
 	Email     string `json:"email" validate:"required,email" example:"user@example.com"`
 	Password  string `json:"password" validate:"required,min=8"`
@@ -19,10 +21,78 @@ type LoginUserResponse struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email" example:"user@example.com"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email" example:"user@example.com"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8"`
+}
+
 type UpdateUserRequest struct {
 	Email     string `json:"email" validate:"omitempty,email" example:"user@example.com"`
 	Password  string `json:"password" validate:"omitempty,min=8"`
 	FirstName string `json:"first_name" validate:"omitempty"`
 	LastName  string `json:"last_name" validate:"omitempty"`
-	Role      string `json:"role" validate:"omitempty,oneof=user admin"`
+	Role      string `json:"role" validate:"omitempty"`
+}
+
+type UpdateMeRequest struct {
+	Email     string `json:"email" validate:"omitempty,email" example:"user@example.com"`
+	FirstName string `json:"first_name" validate:"omitempty"`
+	LastName  string `json:"last_name" validate:"omitempty"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+type ListUsersQuery struct {
+	PageQuery
+	Role         string `query:"role"`
+	IsVerified   *bool  `query:"is_verified"`
+	CreatedAfter string `query:"created_after" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	Email        string `query:"email"`
+	Sort         string `query:"sort"`
+}
+
+type SearchUsersQuery struct {
+	Q     string `query:"q" validate:"required,min=2,max=100"`
+	Limit int    `query:"limit" validate:"omitempty,min=1,max=50"`
+}
+
+type UserSearchResult struct {
+	models.User
+	Rank float64 `json:"rank" example:"0.61"`
+}
+
+type UserSearchResponse struct {
+	Data []UserSearchResult `json:"data"`
+}
+
+type UserListResponse struct {
+	Data []models.User `json:"data"`
+	PageMeta
 }
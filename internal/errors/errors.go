@@ -1,6 +1,7 @@
 package errors
 
 import (
+	"context"
 	"errors"
 
 	"github.com/gofiber/fiber/v2"
@@ -15,18 +16,49 @@ var (
 	ErrBadRequest         = errors.New("bad request")
 	ErrInternalServer     = errors.New("internal server error")
 	ErrTokenInvalid       = errors.New("invalid or expired token")
+	ErrTokenReused        = errors.New("refresh token reuse detected")
+	ErrEmailNotVerified   = errors.New("email not verified")
+	ErrMFAAlreadyEnabled  = errors.New("two-factor authentication already enabled")
+	ErrMFANotEnrolled     = errors.New("two-factor authentication not enrolled")
+	ErrInvalidMFACode     = errors.New("invalid two-factor code")
+	ErrTooManyRequests    = errors.New("too many requests")
+	ErrRequestTimeout     = errors.New("request timed out")
 )
 
+// queryCanceled is the SQLSTATE Postgres reports when statement_timeout or a
+// cancel request stops a query.
+const queryCanceled = "57014"
+
+// IsTimeout reports whether err means the request ran out of time: its
+// context ended or Postgres cancelled the statement.
+func IsTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return true
+	}
+	var sqlErr interface{ SQLState() string }
+	return errors.As(err, &sqlErr) && sqlErr.SQLState() == queryCanceled
+}
+
 type AppError struct {
 	Code    int    `json:"-"`
 	Message string `json:"message"`
 }
 
+// SendError writes the error body, with the request ID so a client report
+// can be matched to the server logs.
 func SendError(c *fiber.Ctx, code int, message string) error {
-	return c.Status(code).JSON(fiber.Map{"error": message})
+	body := fiber.Map{"error": message}
+	if id, ok := c.Locals("requestID").(string); ok {
+		body["request_id"] = id
+	}
+	return c.Status(code).JSON(body)
 }
 
 func HandleError(c *fiber.Ctx, err error) error {
+	if IsTimeout(err) {
+		err = ErrRequestTimeout
+	}
+
 	switch err {
 	case ErrUserNotFound:
 		return SendError(c, fiber.StatusNotFound, err.Error())
@@ -36,10 +68,16 @@ func HandleError(c *fiber.Ctx, err error) error {
 		return SendError(c, fiber.StatusBadRequest, err.Error())
 	case ErrUnauthorized:
 		return SendError(c, fiber.StatusUnauthorized, err.Error())
-	case ErrForbidden:
+	case ErrForbidden, ErrEmailNotVerified:
 		return SendError(c, fiber.StatusForbidden, err.Error())
-	case ErrBadRequest:
+	case ErrBadRequest, ErrMFAAlreadyEnabled, ErrMFANotEnrolled:
 		return SendError(c, fiber.StatusBadRequest, err.Error())
+	case ErrTokenInvalid, ErrTokenReused, ErrInvalidMFACode:
+		return SendError(c, fiber.StatusUnauthorized, err.Error())
+	case ErrTooManyRequests:
+		return SendError(c, fiber.StatusTooManyRequests, err.Error())
+	case ErrRequestTimeout:
+		return SendError(c, fiber.StatusServiceUnavailable, err.Error())
 	default:
 		return SendError(c, fiber.StatusInternalServerError, ErrInternalServer.Error())
 	}
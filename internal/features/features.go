@@ -0,0 +1,34 @@
+// Package features answers whether a feature flag is on. Flags come from
+// FEATURE_FLAGS and can change at runtime through a config reload.
+package features
+
+import "sync"
+
+var (
+	mu    sync.RWMutex
+	flags = map[string]bool{}
+)
+
+// Set replaces every flag.
+func Set(next map[string]bool) {
+	copied := make(map[string]bool, len(next))
+	for name, on := range next {
+		copied[name] = on
+	}
+
+	mu.Lock()
+	flags = copied
+	mu.Unlock()
+}
+
+// All returns a copy of every flag. Flags missing from it are off.
+func All() map[string]bool {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	copied := make(map[string]bool, len(flags))
+	for name, on := range flags {
+		copied[name] = on
+	}
+	return copied
+}
@@ -8,6 +8,7 @@ import (
 	dto "github.com/sudo-hassan-zahid/go-api-server/internal/dto"
 	appErrors "github.com/sudo-hassan-zahid/go-api-server/internal/errors"
 	"github.com/sudo-hassan-zahid/go-api-server/internal/logger"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/metrics"
 	"github.com/sudo-hassan-zahid/go-api-server/internal/service"
 	"github.com/sudo-hassan-zahid/go-api-server/utils"
 	"gorm.io/gorm"
@@ -36,24 +37,24 @@ func NewAuthHandler(s service.AuthService) *AuthHandler {
 func (h *AuthHandler) CreateUser(c *fiber.Ctx) error {
 	var req dto.CreateUserRequest
 	if err := c.BodyParser(&req); err != nil {
-		logger.Log.Error().Err(err).Msg("Failed to parse request body")
+		logger.FromRequest(c).Error().Err(err).Msg("Failed to parse request body")
 		return appErrors.HandleError(c, appErrors.ErrBadRequest)
 	}
 
 	if ok := utils.ValidateStruct(c, &req); !ok {
-		logger.Log.Warn().Msg("Validation failed")
+		logger.FromRequest(c).Warn().Msg("Validation failed")
 		return nil
 	}
 
-	user, err := h.service.CreateUser(req.Email, req.Password, req.FirstName, req.LastName)
+	user, err := h.service.CreateUser(c.UserContext(), req.Email, req.Password, req.FirstName, req.LastName)
 	if err != nil {
-		logger.Log.Error().Err(err).Msg("Failed to create user")
+		logger.FromRequest(c).Error().Err(err).Msg("Failed to create user")
 		if errors.Is(err, gorm.ErrDuplicatedKey) {
 			return appErrors.HandleError(c, appErrors.ErrEmailAlreadyExists)
 		}
 		return appErrors.HandleError(c, err)
 	}
-	logger.Log.Info().Msg("User created successfully")
+	logger.FromRequest(c).Info().Msg("User created successfully")
 	return c.Status(fiber.StatusCreated).JSON(user)
 }
 
@@ -65,8 +66,10 @@ func (h *AuthHandler) CreateUser(c *fiber.Ctx) error {
 // @Produce      json
 // @Param        user body dto.LoginUserRequest true "User credentials"
 // @Success      200 {object} dto.LoginUserResponse "Login successful, returns user object"
+// @Success      202 {object} dto.MFARequiredResponse "Password accepted, two-factor code required at /auth/mfa/verify"
 // @Failure      400 {object} map[string]string "Bad request / validation error"
 // @Failure      401 {object} map[string]string "Invalid credentials"
+// @Failure      403 {object} map[string]string "Email not verified"
 // @Failure      500 {object} map[string]string "Internal server error"
 // @Router       /auth/login [post]
 func (h *AuthHandler) LoginUser(c *fiber.Ctx) error {
@@ -79,25 +82,250 @@ func (h *AuthHandler) LoginUser(c *fiber.Ctx) error {
 		return nil
 	}
 
-	user, err := h.service.LoginUser(req.Email, req.Password)
+	result, err := h.service.LoginUser(c.UserContext(), req.Email, req.Password)
 	if err != nil {
+		metrics.LoginAttempts.WithLabelValues(loginResult(err)).Inc()
 		return appErrors.HandleError(c, err)
 	}
 
-	accessToken, err := auth.GenerateAccessToken(user.ID.String(), user.Role)
-	if err != nil {
-		return appErrors.HandleError(c, err)
+	if result.MFAToken != "" {
+		metrics.LoginAttempts.WithLabelValues(metrics.LoginMFARequired).Inc()
+		return c.Status(fiber.StatusAccepted).JSON(dto.MFARequiredResponse{
+			MFARequired: true,
+			MFAToken:    result.MFAToken,
+		})
+	}
+	metrics.LoginAttempts.WithLabelValues(metrics.LoginOK).Inc()
+
+	return c.Status(fiber.StatusOK).JSON(dto.LoginUserResponse{
+		UserID:       result.User.ID.String(),
+		UserRole:     result.User.Role,
+		AccessToken:  result.Tokens.AccessToken,
+		RefreshToken: result.Tokens.RefreshToken,
+	})
+}
+
+// loginResult labels a failed login step. Only wrong credentials, codes and
+// tokens count as failed; anything else went wrong on our side.
+func loginResult(err error) string {
+	switch err {
+	case appErrors.ErrEmailNotVerified:
+		return metrics.LoginUnverified
+	case appErrors.ErrInvalidCredentials, appErrors.ErrInvalidMFACode, appErrors.ErrTokenInvalid, appErrors.ErrTooManyRequests:
+		return metrics.LoginFailed
+	default:
+		return metrics.LoginError
+	}
+}
+
+// RefreshToken 	 godoc
+// @Summary      Refresh access token
+// @Description  Exchanges a refresh token for a new access/refresh token pair. Each refresh token can be used once; reusing one revokes its whole token family
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        token body dto.RefreshTokenRequest true "Refresh token"
+// @Success      200 {object} dto.LoginUserResponse "New token pair"
+// @Failure      400 {object} map[string]string "Bad request / validation error"
+// @Failure      401 {object} map[string]string "Invalid, expired or reused refresh token"
+// @Failure      500 {object} map[string]string "Internal server error"
+// @Router       /auth/refresh [post]
+func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
+	var req dto.RefreshTokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return appErrors.HandleError(c, appErrors.ErrBadRequest)
+	}
+
+	if ok := utils.ValidateStruct(c, &req); !ok {
+		return nil
 	}
 
-	refreshToken, err := auth.GenerateRefreshToken(user.ID.String())
+	user, tokens, err := h.service.RefreshTokens(c.UserContext(), req.RefreshToken)
 	if err != nil {
+		if errors.Is(err, appErrors.ErrTokenReused) {
+			logger.FromRequest(c).Warn().Msg("Refresh token reuse detected, token family revoked")
+		}
 		return appErrors.HandleError(c, err)
 	}
 
 	return c.Status(fiber.StatusOK).JSON(dto.LoginUserResponse{
 		UserID:       user.ID.String(),
 		UserRole:     user.Role,
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	})
+}
+
+// VerifyEmail 	 godoc
+// @Summary      Verify email address
+// @Description  Marks the account as verified using the single-use token sent by email at signup
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        token body dto.VerifyEmailRequest true "Verification token"
+// @Success      200 {object} dto.SuccessResponse "Email verified"
+// @Failure      400 {object} map[string]string "Bad request / validation error"
+// @Failure      401 {object} map[string]string "Invalid, expired or used token"
+// @Failure      500 {object} map[string]string "Internal server error"
+// @Router       /auth/verify [post]
+func (h *AuthHandler) VerifyEmail(c *fiber.Ctx) error {
+	var req dto.VerifyEmailRequest
+	if err := c.BodyParser(&req); err != nil {
+		return appErrors.HandleError(c, appErrors.ErrBadRequest)
+	}
+
+	if ok := utils.ValidateStruct(c, &req); !ok {
+		return nil
+	}
+
+	if err := h.service.VerifyEmail(c.UserContext(), req.Token); err != nil {
+		return appErrors.HandleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.SuccessResponse{Message: "email verified"})
+}
+
+// ResendVerification godoc
+// @Summary      Resend verification email
+// @Description  Sends a new verification token if the address belongs to an unverified account. Always returns 202
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        user body dto.ResendVerificationRequest true "Account email"
+// @Success      202 {object} dto.SuccessResponse "Request accepted"
+// @Failure      400 {object} map[string]string "Bad request / validation error"
+// @Failure      500 {object} map[string]string "Internal server error"
+// @Router       /auth/verify/resend [post]
+func (h *AuthHandler) ResendVerification(c *fiber.Ctx) error {
+	var req dto.ResendVerificationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return appErrors.HandleError(c, appErrors.ErrBadRequest)
+	}
+
+	if ok := utils.ValidateStruct(c, &req); !ok {
+		return nil
+	}
+
+	if err := h.service.ResendVerification(c.UserContext(), utils.SanitizeEmail(req.Email)); err != nil {
+		return appErrors.HandleError(c, err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(dto.SuccessResponse{
+		Message: "if the account exists and is unverified, a verification email has been sent",
 	})
 }
+
+// ForgotPassword godoc
+// @Summary      Request a password reset
+// @Description  Emails a password reset token if the address belongs to an account. Always returns 202
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        user body dto.ForgotPasswordRequest true "Account email"
+// @Success      202 {object} dto.SuccessResponse "Request accepted"
+// @Failure      400 {object} map[string]string "Bad request / validation error"
+// @Router       /auth/password/forgot [post]
+func (h *AuthHandler) ForgotPassword(c *fiber.Ctx) error {
+	var req dto.ForgotPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return appErrors.HandleError(c, appErrors.ErrBadRequest)
+	}
+
+	if ok := utils.ValidateStruct(c, &req); !ok {
+		return nil
+	}
+
+	if err := h.service.ForgotPassword(c.UserContext(), utils.SanitizeEmail(req.Email)); err != nil {
+		logger.FromRequest(c).Error().Err(err).Msg("Failed to process password reset request")
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(dto.SuccessResponse{
+		Message: "if the account exists, a password reset email has been sent",
+	})
+}
+
+// ResetPassword godoc
+// @Summary      Reset password
+// @Description  Sets a new password using a single-use reset token and revokes every session of the account
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        reset body dto.ResetPasswordRequest true "Reset token and new password"
+// @Success      200 {object} dto.SuccessResponse "Password reset"
+// @Failure      400 {object} map[string]string "Bad request / validation error"
+// @Failure      401 {object} map[string]string "Invalid, expired or used token"
+// @Failure      500 {object} map[string]string "Internal server error"
+// @Router       /auth/password/reset [post]
+func (h *AuthHandler) ResetPassword(c *fiber.Ctx) error {
+	var req dto.ResetPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return appErrors.HandleError(c, appErrors.ErrBadRequest)
+	}
+
+	if ok := utils.ValidateStruct(c, &req); !ok {
+		return nil
+	}
+
+	if err := h.service.ResetPassword(c.UserContext(), req.Token, req.Password); err != nil {
+		return appErrors.HandleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.SuccessResponse{Message: "password has been reset"})
+}
+
+// Logout 	 godoc
+// @Summary      Logout
+// @Description  Revokes the current access token and, if given, the refresh token family it was issued with
+// @Tags         Auth
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        token body dto.LogoutRequest false "Refresh token to revoke"
+// @Success      200 {object} dto.SuccessResponse "Logged out"
+// @Failure      400 {object} map[string]string "Bad request"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      500 {object} map[string]string "Internal server error"
+// @Router       /auth/logout [post]
+func (h *AuthHandler) Logout(c *fiber.Ctx) error {
+	var req dto.LogoutRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return appErrors.HandleError(c, appErrors.ErrBadRequest)
+		}
+	}
+
+	claims, ok := c.Locals("claims").(*auth.Claims)
+	if !ok {
+		return appErrors.HandleError(c, appErrors.ErrUnauthorized)
+	}
+
+	if err := h.service.Logout(c.UserContext(), claims, req.RefreshToken); err != nil {
+		return appErrors.HandleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.SuccessResponse{Message: "logged out"})
+}
+
+// LogoutAll 	 godoc
+// @Summary      Logout from all sessions
+// @Description  Revokes every access and refresh token issued to the current user
+// @Tags         Auth
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200 {object} dto.SuccessResponse "Logged out everywhere"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      500 {object} map[string]string "Internal server error"
+// @Router       /auth/logout-all [post]
+func (h *AuthHandler) LogoutAll(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return appErrors.HandleError(c, appErrors.ErrUnauthorized)
+	}
+
+	if err := h.service.LogoutAll(c.UserContext(), userID); err != nil {
+		return appErrors.HandleError(c, err)
+	}
+
+	logger.FromRequest(c).Info().Str("user_id", userID).Msg("All sessions revoked")
+	return c.Status(fiber.StatusOK).JSON(dto.SuccessResponse{Message: "logged out from all sessions"})
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/health"
+)
+
+type HealthHandler struct {
+	registry *health.Registry
+}
+
+func NewHealthHandler(registry *health.Registry) *HealthHandler {
+	return &HealthHandler{registry: registry}
+}
+
+// Livez reports that the process is up and serving. It checks no
+// dependencies, so an outage elsewhere never gets the process restarted.
+func (h *HealthHandler) Livez(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": health.StatusOK})
+}
+
+// Readyz runs the registered checks and answers 503 when one fails or the
+// server is shutting down, so the instance is taken out of rotation.
+func (h *HealthHandler) Readyz(c *fiber.Ctx) error {
+	report := h.registry.Check(c.UserContext())
+
+	status := fiber.StatusOK
+	if report.Status != health.StatusOK {
+		status = fiber.StatusServiceUnavailable
+	}
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(status).JSON(report)
+}
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	dto "github.com/sudo-hassan-zahid/go-api-server/internal/dto"
+	appErrors "github.com/sudo-hassan-zahid/go-api-server/internal/errors"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/logger"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/service"
+	"github.com/sudo-hassan-zahid/go-api-server/utils"
+)
+
+type MeHandler struct {
+	users service.UserService
+	auth  service.AuthService
+}
+
+func NewMeHandler(users service.UserService, auth service.AuthService) *MeHandler {
+	return &MeHandler{users: users, auth: auth}
+}
+
+// GetMe 		 godoc
+// @Summary      Get current user
+// @Description  Returns the account of the authenticated user
+// @Tags         Me
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200 {object} models.User "Current user"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "User not found"
+// @Failure      500 {object} map[string]string "Internal server error"
+// @Router       /me [get]
+func (h *MeHandler) GetMe(c *fiber.Ctx) error {
+	id, err := currentUserID(c)
+	if err != nil {
+		return appErrors.HandleError(c, err)
+	}
+
+	user, err := h.users.GetUserByID(c.UserContext(), id)
+	if err != nil {
+		return appErrors.HandleError(c, err)
+	}
+	return c.JSON(user)
+}
+
+// UpdateMe 	 godoc
+// @Summary      Update current user
+// @Description  Updates the name or email of the authenticated user. A new email address has to be verified again
+// @Tags         Me
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        user body dto.UpdateMeRequest true "Fields to update"
+// @Success      200 {object} models.User "Updated user"
+// @Failure      400 {object} map[string]string "Validation error / email already exists"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      500 {object} map[string]string "Internal server error"
+// @Router       /me [patch]
+func (h *MeHandler) UpdateMe(c *fiber.Ctx) error {
+	id, err := currentUserID(c)
+	if err != nil {
+		return appErrors.HandleError(c, err)
+	}
+
+	var req dto.UpdateMeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return appErrors.HandleError(c, appErrors.ErrBadRequest)
+	}
+
+	if ok := utils.ValidateStruct(c, &req); !ok {
+		return nil
+	}
+
+	user, err := h.users.UpdateUser(c.UserContext(), id, dto.UpdateUserRequest{
+		Email:     req.Email,
+		FirstName: req.FirstName,
+		LastName:  req.LastName,
+	}, false)
+	if err != nil {
+		return appErrors.HandleError(c, err)
+	}
+	return c.JSON(user)
+}
+
+// ChangePassword godoc
+// @Summary      Change password
+// @Description  Changes the password of the authenticated user. All other sessions are revoked and a new token pair is returned
+// @Tags         Me
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        password body dto.ChangePasswordRequest true "Current and new password"
+// @Success      200 {object} dto.LoginUserResponse "New token pair"
+// @Failure      400 {object} map[string]string "Validation error"
+// @Failure      401 {object} map[string]string "Unauthorized / wrong current password"
+// @Failure      500 {object} map[string]string "Internal server error"
+// @Router       /me/password [post]
+func (h *MeHandler) ChangePassword(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return appErrors.HandleError(c, appErrors.ErrUnauthorized)
+	}
+
+	var req dto.ChangePasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return appErrors.HandleError(c, appErrors.ErrBadRequest)
+	}
+
+	if ok := utils.ValidateStruct(c, &req); !ok {
+		return nil
+	}
+
+	user, tokens, err := h.auth.ChangePassword(c.UserContext(), userID, req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		return appErrors.HandleError(c, err)
+	}
+
+	logger.FromRequest(c).Info().Str("user_id", userID).Msg("Password changed, other sessions revoked")
+	return c.Status(fiber.StatusOK).JSON(dto.LoginUserResponse{
+		UserID:       user.ID.String(),
+		UserRole:     user.Role,
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	})
+}
+
+// DeleteMe 	 godoc
+// @Summary      Delete current user
+// @Description  Deletes the account of the authenticated user after confirming the password, and revokes all of its sessions
+// @Tags         Me
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        password body dto.DeleteAccountRequest true "Password confirmation"
+// @Success      200 {object} dto.SuccessResponse "Account deleted"
+// @Failure      400 {object} map[string]string "Validation error"
+// @Failure      401 {object} map[string]string "Unauthorized / wrong password"
+// @Failure      500 {object} map[string]string "Internal server error"
+// @Router       /me [delete]
+func (h *MeHandler) DeleteMe(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return appErrors.HandleError(c, appErrors.ErrUnauthorized)
+	}
+
+	var req dto.DeleteAccountRequest
+	if err := c.BodyParser(&req); err != nil {
+		return appErrors.HandleError(c, appErrors.ErrBadRequest)
+	}
+
+	if ok := utils.ValidateStruct(c, &req); !ok {
+		return nil
+	}
+
+	if err := h.auth.DeleteAccount(c.UserContext(), userID, req.Password); err != nil {
+		return appErrors.HandleError(c, err)
+	}
+
+	logger.FromRequest(c).Info().Str("user_id", userID).Msg("Account deleted by its owner")
+	return c.Status(fiber.StatusOK).JSON(dto.SuccessResponse{Message: "account deleted"})
+}
+
+func currentUserID(c *fiber.Ctx) (uuid.UUID, error) {
+	userID, _ := c.Locals("userID").(string)
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return uuid.Nil, appErrors.ErrUnauthorized
+	}
+	return id, nil
+}
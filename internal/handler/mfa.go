@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	dto "github.com/sudo-hassan-zahid/go-api-server/internal/dto"
+	appErrors "github.com/sudo-hassan-zahid/go-api-server/internal/errors"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/logger"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/metrics"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/service"
+	"github.com/sudo-hassan-zahid/go-api-server/utils"
+)
+
+type MFAHandler struct {
+	service service.MFAService
+}
+
+func NewMFAHandler(s service.MFAService) *MFAHandler {
+	return &MFAHandler{service: s}
+}
+
+// Enroll 		 godoc
+// @Summary      Start two-factor enrollment
+// @Description  Generates a TOTP secret and the otpauth URI to render as a QR code. Two-factor authentication stays off until confirmed
+// @Tags         MFA
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200 {object} dto.MFAEnrollResponse "TOTP secret and otpauth URI"
+// @Failure      400 {object} map[string]string "Already enabled"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      500 {object} map[string]string "Internal server error"
+// @Router       /auth/mfa/enroll [post]
+func (h *MFAHandler) Enroll(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return appErrors.HandleError(c, appErrors.ErrUnauthorized)
+	}
+
+	enrollment, err := h.service.Enroll(c.UserContext(), userID)
+	if err != nil {
+		return appErrors.HandleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.MFAEnrollResponse{
+		Secret:     enrollment.Secret,
+		OTPAuthURI: enrollment.URI,
+	})
+}
+
+// Confirm 		 godoc
+// @Summary      Confirm two-factor enrollment
+// @Description  Enables two-factor authentication with a code from the authenticator app and returns single-use recovery codes
+// @Tags         MFA
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        code body dto.MFACodeRequest true "TOTP code"
+// @Success      200 {object} dto.MFARecoveryCodesResponse "Recovery codes, shown only once"
+// @Failure      400 {object} map[string]string "Bad request / not enrolled / already enabled"
+// @Failure      401 {object} map[string]string "Invalid code"
+// @Failure      429 {object} map[string]string "Too many wrong codes, try again later"
+// @Failure      500 {object} map[string]string "Internal server error"
+// @Router       /auth/mfa/confirm [post]
+func (h *MFAHandler) Confirm(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return appErrors.HandleError(c, appErrors.ErrUnauthorized)
+	}
+
+	var req dto.MFACodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return appErrors.HandleError(c, appErrors.ErrBadRequest)
+	}
+
+	if ok := utils.ValidateStruct(c, &req); !ok {
+		return nil
+	}
+
+	codes, err := h.service.Confirm(c.UserContext(), userID, req.Code)
+	if err != nil {
+		return appErrors.HandleError(c, err)
+	}
+
+	logger.FromRequest(c).Info().Str("user_id", userID).Msg("Two-factor authentication enabled")
+	return c.Status(fiber.StatusOK).JSON(dto.MFARecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// Disable 		 godoc
+// @Summary      Disable two-factor authentication
+// @Description  Turns two-factor authentication off after checking a TOTP or recovery code
+// @Tags         MFA
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        code body dto.MFACodeRequest true "TOTP or recovery code"
+// @Success      200 {object} dto.SuccessResponse "Two-factor authentication disabled"
+// @Failure      400 {object} map[string]string "Bad request / not enabled"
+// @Failure      401 {object} map[string]string "Invalid code"
+// @Failure      429 {object} map[string]string "Too many wrong codes, try again later"
+// @Failure      500 {object} map[string]string "Internal server error"
+// @Router       /auth/mfa/disable [post]
+func (h *MFAHandler) Disable(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return appErrors.HandleError(c, appErrors.ErrUnauthorized)
+	}
+
+	var req dto.MFACodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return appErrors.HandleError(c, appErrors.ErrBadRequest)
+	}
+
+	if ok := utils.ValidateStruct(c, &req); !ok {
+		return nil
+	}
+
+	if err := h.service.Disable(c.UserContext(), userID, req.Code); err != nil {
+		return appErrors.HandleError(c, err)
+	}
+
+	logger.FromRequest(c).Info().Str("user_id", userID).Msg("Two-factor authentication disabled")
+	return c.Status(fiber.StatusOK).JSON(dto.SuccessResponse{Message: "two-factor authentication disabled"})
+}
+
+// Verify 		 godoc
+// @Summary      Complete two-factor login
+// @Description  Exchanges the mfa_token returned by login and a TOTP or recovery code for access and refresh tokens. After 5 wrong codes the mfa_token is revoked and the user has to log in again
+// @Tags         MFA
+// @Accept       json
+// @Produce      json
+// @Param        mfa body dto.MFAVerifyRequest true "MFA token and code"
+// @Success      200 {object} dto.LoginUserResponse "Login successful"
+// @Failure      400 {object} map[string]string "Bad request / validation error"
+// @Failure      401 {object} map[string]string "Invalid token or code"
+// @Failure      500 {object} map[string]string "Internal server error"
+// @Router       /auth/mfa/verify [post]
+func (h *MFAHandler) Verify(c *fiber.Ctx) error {
+	var req dto.MFAVerifyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return appErrors.HandleError(c, appErrors.ErrBadRequest)
+	}
+
+	if ok := utils.ValidateStruct(c, &req); !ok {
+		return nil
+	}
+
+	user, tokens, err := h.service.Verify(c.UserContext(), req.MFAToken, req.Code)
+	if err != nil {
+		metrics.LoginAttempts.WithLabelValues(loginResult(err)).Inc()
+		return appErrors.HandleError(c, err)
+	}
+	metrics.LoginAttempts.WithLabelValues(metrics.LoginOK).Inc()
+
+	return c.Status(fiber.StatusOK).JSON(dto.LoginUserResponse{
+		UserID:       user.ID.String(),
+		UserRole:     user.Role,
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	})
+}
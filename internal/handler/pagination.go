@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	dto "github.com/sudo-hassan-zahid/go-api-server/internal/dto"
+	appErrors "github.com/sudo-hassan-zahid/go-api-server/internal/errors"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/repository"
+	"github.com/valyala/fasthttp"
+)
+
+const defaultPageSize = 20
+
+// readPage normalizes the shared pagination parameters and decodes the
+// cursor, if any, which must have been built for sort. A cursor takes
+// precedence over page.
+func readPage(q *dto.PageQuery, sort string) (*repository.Cursor, error) {
+	if q.Page == 0 {
+		q.Page = 1
+	}
+	if q.PageSize == 0 {
+		q.PageSize = defaultPageSize
+	}
+
+	if q.Cursor == "" {
+		return nil, nil
+	}
+	cursor, err := repository.DecodeCursor(q.Cursor, sort)
+	if err != nil {
+		return nil, appErrors.ErrBadRequest
+	}
+	return cursor, nil
+}
+
+// offset returns the row offset of an offset-paginated request.
+func offset(q dto.PageQuery) int {
+	return (q.Page - 1) * q.PageSize
+}
+
+// writePage fills the page metadata and next/prev links of a list response.
+func writePage(c *fiber.Ctx, q dto.PageQuery, total int64, next *repository.Cursor) dto.PageMeta {
+	meta := dto.PageMeta{
+		Total:    total,
+		PageSize: q.PageSize,
+	}
+
+	if next != nil {
+		meta.NextCursor = next.Encode()
+	}
+
+	if q.Cursor != "" {
+		if meta.NextCursor != "" {
+			meta.Links.Next = pageLink(c, "cursor", meta.NextCursor)
+		}
+		return meta
+	}
+
+	meta.Page = q.Page
+	if int64(q.Page*q.PageSize) < total {
+		meta.Links.Next = pageLink(c, "page", strconv.Itoa(q.Page+1))
+	}
+	if q.Page > 1 {
+		meta.Links.Prev = pageLink(c, "page", strconv.Itoa(q.Page-1))
+	}
+	return meta
+}
+
+// pageLink rebuilds the request URL with key set to value, dropping the
+// other pagination parameter.
+func pageLink(c *fiber.Ctx, key, value string) string {
+	args := fasthttp.AcquireArgs()
+	defer fasthttp.ReleaseArgs(args)
+
+	c.Request().URI().QueryArgs().CopyTo(args)
+	args.Del("page")
+	args.Del("cursor")
+	args.Set(key, value)
+	return c.Path() + "?" + args.String()
+}
@@ -1,6 +1,10 @@
 package handler
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/auth"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/features"
+)
 
 type PublicHandler struct{}
 
@@ -20,3 +24,21 @@ func (h *PublicHandler) HealthCheck(c *fiber.Ctx) error {
 		"status": "ok",
 	})
 }
+
+// @Summary     Feature flags
+// @Description Lists the feature flags from FEATURE_FLAGS so clients can toggle features. Flags missing from the list are off. Changes apply on config reload
+// @Tags        Features
+// @Produce     json
+// @Success 	200 {object} map[string]bool
+// @Router      /features [get]
+func (h *PublicHandler) Features(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(fiber.StatusOK).JSON(features.All())
+}
+
+// JWKS serves the public verification keys at /.well-known/jwks.json, outside
+// the /api base path, so other services can verify our tokens by kid.
+func (h *PublicHandler) JWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.Status(fiber.StatusOK).JSON(auth.JWKS())
+}
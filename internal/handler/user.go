@@ -1,11 +1,18 @@
 package handler
 
 import (
-	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/auth"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/constants"
+	dto "github.com/sudo-hassan-zahid/go-api-server/internal/dto"
 	appErrors "github.com/sudo-hassan-zahid/go-api-server/internal/errors"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/logger"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/repository"
 	"github.com/sudo-hassan-zahid/go-api-server/internal/service"
+	"github.com/sudo-hassan-zahid/go-api-server/utils"
 )
 
 type UserHandler struct {
@@ -16,27 +23,118 @@ func NewUserHandler(s service.UserService) *UserHandler {
 	return &UserHandler{service: s}
 }
 
-// GetAllUsers 	 godoc
-// @Summary      Get all users
-// @Description  Returns a list of all existing users
+// ListUsers 	 godoc
+// @Summary      List users
+// @Description  Returns one page of users, selected by page/page_size or by the cursor from a previous page (created_at sort only)
 // @Tags         Users
 // @Security     BearerAuth
 // @Accept       json
 // @Produce      json
-// @Success      200 {array} models.User "List of users"
+// @Param        page query int false "Page number, starting at 1"
+// @Param        page_size query int false "Users per page (max 100)" default(20)
+// @Param        cursor query string false "next_cursor of the previous page"
+// @Param        role query string false "Filter by role, one of the configured roles"
+// @Param        is_verified query bool false "Filter by verification status"
+// @Param        created_after query string false "Only users created after this RFC 3339 time"
+// @Param        email query string false "Filter by email prefix"
+// @Param        sort query string false "Sort field, prefix with - for descending" Enums(created_at, -created_at, updated_at, -updated_at, email, -email, first_name, -first_name, last_name, -last_name, role, -role)
+// @Success      200 {object} dto.UserListResponse "Page of users"
+// @Failure      400 {object} map[string]string "Invalid query parameters"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      403 {object} map[string]string "Missing users:read permission"
 // @Failure      500 {object} map[string]string "Internal server error"
 // @Router       /users [get]
-func (h *UserHandler) GetAllUsers(c *fiber.Ctx) error {
-	users, err := h.service.GetAllUsers()
+func (h *UserHandler) ListUsers(c *fiber.Ctx) error {
+	var query dto.ListUsersQuery
+	if err := c.QueryParser(&query); err != nil {
+		return appErrors.HandleError(c, appErrors.ErrBadRequest)
+	}
+
+	if ok := utils.ValidateStruct(c, &query); !ok {
+		return nil
+	}
+
+	filter := repository.UserFilter{
+		Role:        query.Role,
+		IsVerified:  query.IsVerified,
+		EmailPrefix: utils.SanitizeEmail(query.Email),
+	}
+	if query.CreatedAfter != "" {
+		createdAfter, err := time.Parse(time.RFC3339, query.CreatedAfter)
+		if err != nil {
+			return appErrors.HandleError(c, appErrors.ErrBadRequest)
+		}
+		filter.CreatedAfter = &createdAfter
+	}
+
+	if query.Sort == "" {
+		query.Sort = repository.DefaultUserSort
+	}
+	after, err := readPage(&query.PageQuery, query.Sort)
+	if err != nil {
+		return appErrors.HandleError(c, err)
+	}
+
+	users, total, next, err := h.service.ListUsers(c.UserContext(), filter, repository.UserListOptions{
+		Sort:   query.Sort,
+		Limit:  query.PageSize,
+		Offset: offset(query.PageQuery),
+		After:  after,
+	})
+	if err != nil {
+		return appErrors.HandleError(c, err)
+	}
+
+	resp := dto.UserListResponse{
+		Data:     users,
+		PageMeta: writePage(c, query.PageQuery, total, next),
+	}
+	return c.JSON(resp)
+}
+
+// SearchUsers 	 godoc
+// @Summary      Search users
+// @Description  Finds users by partial or misspelled name or email, best matches first
+// @Tags         Users
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        q query string true "Search text (2-100 characters)"
+// @Param        limit query int false "Maximum number of results (max 50)" default(20)
+// @Success      200 {object} dto.UserSearchResponse "Ranked matches"
+// @Failure      400 {object} map[string]string "Invalid query parameters"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      403 {object} map[string]string "Missing users:read permission"
+// @Failure      500 {object} map[string]string "Internal server error"
+// @Router       /users/search [get]
+func (h *UserHandler) SearchUsers(c *fiber.Ctx) error {
+	var query dto.SearchUsersQuery
+	if err := c.QueryParser(&query); err != nil {
+		return appErrors.HandleError(c, appErrors.ErrBadRequest)
+	}
+
+	if ok := utils.ValidateStruct(c, &query); !ok {
+		return nil
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultPageSize
+	}
+
+	results, err := h.service.SearchUsers(c.UserContext(), query.Q, query.Limit)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return appErrors.HandleError(c, err)
 	}
-	return c.JSON(users)
+
+	resp := dto.UserSearchResponse{Data: make([]dto.UserSearchResult, len(results))}
+	for i, r := range results {
+		resp.Data[i] = dto.UserSearchResult{User: r.User, Rank: r.Rank}
+	}
+	return c.JSON(resp)
 }
 
 // GetUserByID 	 godoc
 // @Summary      Get user by ID
-// @Description  Returns a single user by their ID
+// @Description  Returns a single user by their ID. Users can read their own account; reading others requires the users:read permission
 // @Tags         Users
 // @Security     BearerAuth
 // @Accept       json
@@ -44,19 +142,146 @@ func (h *UserHandler) GetAllUsers(c *fiber.Ctx) error {
 // @Param        id path string true "User UUID"
 // @Success      200 {object} models.User "User found"
 // @Failure      400 {object} map[string]string "Invalid ID"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      403 {object} map[string]string "Forbidden"
 // @Failure      404 {object} map[string]string "User not found"
 // @Failure      500 {object} map[string]string "Internal server error"
 // @Router       /users/{id} [get]
 func (h *UserHandler) GetUserByID(c *fiber.Ctx) error {
-	idParam := c.Params("id")
-	id, err := strconv.ParseUint(idParam, 10, 32)
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return appErrors.HandleError(c, appErrors.ErrBadRequest)
+	}
+
+	if !isSelfOrHasPermission(c, id, constants.PERM_USERS_READ) {
+		return appErrors.HandleError(c, appErrors.ErrForbidden)
+	}
+
+	user, err := h.service.GetUserByID(c.UserContext(), id)
+	if err != nil {
+		return appErrors.HandleError(c, err)
+	}
+	return c.JSON(user)
+}
+
+// UpdateUser 	 godoc
+// @Summary      Update user
+// @Description  Updates the given fields of a user. Users can update their own account except for the password, which is changed through /me/password; updating others requires users:write and changing a role requires users:admin. Setting a password or changing the role revokes the user's sessions
+// @Tags         Users
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "User UUID"
+// @Param        user body dto.UpdateUserRequest true "Fields to update"
+// @Success      200 {object} models.User "Updated user"
+// @Failure      400 {object} map[string]string "Invalid ID / validation error / unknown role / email already exists"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      403 {object} map[string]string "Forbidden"
+// @Failure      404 {object} map[string]string "User not found"
+// @Failure      500 {object} map[string]string "Internal server error"
+// @Router       /users/{id} [patch]
+func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return appErrors.HandleError(c, appErrors.ErrBadRequest)
+	}
+
+	if !isSelfOrHasPermission(c, id, constants.PERM_USERS_WRITE) {
+		return appErrors.HandleError(c, appErrors.ErrForbidden)
+	}
+
+	var req dto.UpdateUserRequest
+	if err := c.BodyParser(&req); err != nil {
+		return appErrors.HandleError(c, appErrors.ErrBadRequest)
+	}
+
+	if ok := utils.ValidateStruct(c, &req); !ok {
+		return nil
+	}
+
+	// Changing one's own password must go through /me/password, which checks
+	// the current password first.
+	if req.Password != "" && isSelf(c, id) {
+		return appErrors.HandleError(c, appErrors.ErrForbidden)
+	}
+
+	role, _ := c.Locals("role").(string)
+	user, err := h.service.UpdateUser(c.UserContext(), id, req, auth.HasPermission(role, constants.PERM_USERS_ADMIN))
+	if err != nil {
+		return appErrors.HandleError(c, err)
+	}
+	return c.JSON(user)
+}
+
+// DeleteUser 	 godoc
+// @Summary      Delete user
+// @Description  Soft deletes a user and revokes their sessions. The account can be restored later
+// @Tags         Users
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path string true "User UUID"
+// @Success      200 {object} dto.SuccessResponse "User deleted"
+// @Failure      400 {object} map[string]string "Invalid ID"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      403 {object} map[string]string "Missing users:write permission"
+// @Failure      404 {object} map[string]string "User not found"
+// @Failure      500 {object} map[string]string "Internal server error"
+// @Router       /users/{id} [delete]
+func (h *UserHandler) DeleteUser(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
 	if err != nil {
 		return appErrors.HandleError(c, appErrors.ErrBadRequest)
 	}
 
-	user, err := h.service.GetUserByID(uint(id))
+	if err := h.service.DeleteUser(c.UserContext(), id); err != nil {
+		return appErrors.HandleError(c, err)
+	}
+
+	logger.FromRequest(c).Info().Str("user_id", id.String()).Msg("User deleted")
+	return c.JSON(dto.SuccessResponse{Message: "user deleted"})
+}
+
+// RestoreUser 	 godoc
+// @Summary      Restore user
+// @Description  Restores a soft deleted user
+// @Tags         Users
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path string true "User UUID"
+// @Success      200 {object} models.User "Restored user"
+// @Failure      400 {object} map[string]string "Invalid ID"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      403 {object} map[string]string "Missing users:write permission"
+// @Failure      404 {object} map[string]string "No deleted user with this ID"
+// @Failure      500 {object} map[string]string "Internal server error"
+// @Router       /users/{id}/restore [post]
+func (h *UserHandler) RestoreUser(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return appErrors.HandleError(c, appErrors.ErrBadRequest)
+	}
+
+	user, err := h.service.RestoreUser(c.UserContext(), id)
 	if err != nil {
 		return appErrors.HandleError(c, err)
 	}
+
+	logger.FromRequest(c).Info().Str("user_id", id.String()).Msg("User restored")
 	return c.JSON(user)
 }
+
+// isSelf reports whether the caller is the user with the given ID.
+func isSelf(c *fiber.Ctx, id uuid.UUID) bool {
+	userID, _ := c.Locals("userID").(string)
+	return userID == id.String()
+}
+
+// isSelfOrHasPermission reports whether the caller is the user with the given
+// ID or holds permission, which lets admins act on any account.
+func isSelfOrHasPermission(c *fiber.Ctx, id uuid.UUID, permission string) bool {
+	if isSelf(c, id) {
+		return true
+	}
+	role, _ := c.Locals("role").(string)
+	return auth.HasPermission(role, permission)
+}
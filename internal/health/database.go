@@ -0,0 +1,39 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+)
+
+// PoolStats is the part of sql.DBStats that shows pool pressure.
+// Saturation is InUse / MaxOpen, or 0 when the pool is unbounded.
+type PoolStats struct {
+	MaxOpen      int     `json:"max_open"`
+	Open         int     `json:"open"`
+	InUse        int     `json:"in_use"`
+	Idle         int     `json:"idle"`
+	WaitCount    int64   `json:"wait_count"`
+	WaitDuration string  `json:"wait_duration"`
+	Saturation   float64 `json:"saturation"`
+}
+
+// Database pings db and reports its connection pool stats.
+func Database(db *sql.DB) HealthChecker {
+	return CheckerFunc("database", func(ctx context.Context) (interface{}, error) {
+		err := db.PingContext(ctx)
+
+		s := db.Stats()
+		stats := PoolStats{
+			MaxOpen:      s.MaxOpenConnections,
+			Open:         s.OpenConnections,
+			InUse:        s.InUse,
+			Idle:         s.Idle,
+			WaitCount:    s.WaitCount,
+			WaitDuration: s.WaitDuration.String(),
+		}
+		if s.MaxOpenConnections > 0 {
+			stats.Saturation = float64(s.InUse) / float64(s.MaxOpenConnections)
+		}
+		return stats, err
+	})
+}
@@ -0,0 +1,116 @@
+// Package health runs the dependency checks behind the readiness probe.
+// Packages that own a dependency register a HealthChecker for it.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK           = "ok"
+	StatusFail         = "fail"
+	StatusShuttingDown = "shutting_down"
+)
+
+// DefaultTimeout bounds a readiness run when the registry has no timeout set.
+const DefaultTimeout = 2 * time.Second
+
+// HealthChecker checks one dependency. Details are optional and reported
+// as they are, whether or not the check passed.
+type HealthChecker interface {
+	Name() string
+	Check(ctx context.Context) (details interface{}, err error)
+}
+
+type CheckResult struct {
+	Status  string      `json:"status"`
+	Error   string      `json:"error,omitempty"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+type Registry struct {
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+
+	mu       sync.RWMutex
+	checkers []HealthChecker
+}
+
+func NewRegistry(timeout time.Duration) *Registry {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Registry{timeout: timeout}
+}
+
+func (r *Registry) Register(c HealthChecker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checkers = append(r.checkers, c)
+}
+
+// SetShuttingDown makes every later readiness check fail, so load balancers
+// stop sending traffic before the server stops accepting it.
+func (r *Registry) SetShuttingDown() {
+	r.shuttingDown.Store(true)
+}
+
+// Check runs every checker concurrently within the registry timeout.
+func (r *Registry) Check(ctx context.Context) Report {
+	r.mu.RLock()
+	checkers := append([]HealthChecker(nil), r.checkers...)
+	r.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	results := make([]CheckResult, len(checkers))
+	var wg sync.WaitGroup
+	for i, c := range checkers {
+		wg.Add(1)
+		go func(i int, c HealthChecker) {
+			defer wg.Done()
+			details, err := c.Check(ctx)
+			results[i] = CheckResult{Status: StatusOK, Details: details}
+			if err != nil {
+				results[i].Status = StatusFail
+				results[i].Error = err.Error()
+			}
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(checkers))}
+	for i, c := range checkers {
+		report.Checks[c.Name()] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	if r.shuttingDown.Load() {
+		report.Status = StatusShuttingDown
+	}
+	return report
+}
+
+// CheckerFunc adapts a function to HealthChecker.
+func CheckerFunc(name string, fn func(ctx context.Context) (interface{}, error)) HealthChecker {
+	return checkerFunc{name: name, fn: fn}
+}
+
+type checkerFunc struct {
+	name string
+	fn   func(ctx context.Context) (interface{}, error)
+}
+
+func (c checkerFunc) Name() string { return c.name }
+
+func (c checkerFunc) Check(ctx context.Context) (interface{}, error) { return c.fn(ctx) }
@@ -0,0 +1,33 @@
+package logger
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+)
+
+type ctxKey struct{}
+
+// WithContext returns a copy of ctx carrying l, typically a child of Log with
+// request fields attached.
+func WithContext(ctx context.Context, l zerolog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, &l)
+}
+
+// FromContext returns the logger stored in ctx by WithContext, or Log when
+// there is none, so code running outside a request can use it too.
+func FromContext(ctx context.Context) *zerolog.Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(ctxKey{}).(*zerolog.Logger); ok {
+			return l
+		}
+	}
+	return &Log
+}
+
+// FromRequest returns the request-scoped logger of c. Handlers use it instead
+// of Log so every line carries the request ID.
+func FromRequest(c *fiber.Ctx) *zerolog.Logger {
+	return FromContext(c.UserContext())
+}
@@ -14,12 +14,7 @@ import (
 var Log = log.Logger
 
 func Init(cfg config.LogConfig, env string) {
-	level, err := zerolog.ParseLevel(strings.ToLower(cfg.Level))
-	if err != nil {
-		level = zerolog.InfoLevel
-	}
-
-	zerolog.SetGlobalLevel(level)
+	SetLevel(cfg.Level)
 
 	if env == "local" {
 		Log = log.Output(zerolog.ConsoleWriter{
@@ -30,3 +25,12 @@ func Init(cfg config.LogConfig, env string) {
 
 	Log.Info().Str("env", env).Msg("Logger initialized")
 }
+
+// SetLevel changes the global log level; unknown levels fall back to info.
+func SetLevel(name string) {
+	level, err := zerolog.ParseLevel(strings.ToLower(name))
+	if err != nil {
+		level = zerolog.InfoLevel
+	}
+	zerolog.SetGlobalLevel(level)
+}
@@ -0,0 +1,24 @@
+package mailer
+
+import "github.com/sudo-hassan-zahid/go-api-server/internal/logger"
+
+// LogMailer writes messages to the application log instead of delivering
+// them, for local runs. Bodies carry one-time tokens, so they are only
+// logged at debug level.
+type LogMailer struct{}
+
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+func (m *LogMailer) Send(msg Message) error {
+	logger.Log.Info().
+		Str("to", msg.To).
+		Str("subject", msg.Subject).
+		Msg("Mail sent")
+	logger.Log.Debug().
+		Str("to", msg.To).
+		Str("body", msg.Body).
+		Msg("Mail body")
+	return nil
+}
@@ -0,0 +1,33 @@
+package mailer
+
+import (
+	"fmt"
+
+	"github.com/sudo-hassan-zahid/go-api-server/internal/config"
+)
+
+const (
+	DriverLog  = "log"
+	DriverSMTP = "smtp"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(msg Message) error
+}
+
+func New(cfg config.MailConfig) (Mailer, error) {
+	switch cfg.Driver {
+	case DriverLog, "":
+		return NewLogMailer(), nil
+	case DriverSMTP:
+		return NewSMTPMailer(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+	}
+}
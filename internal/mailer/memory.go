@@ -0,0 +1,45 @@
+package mailer
+
+import "sync"
+
+// MemoryMailer keeps every message in memory instead of delivering it, for
+// tests that need to read the tokens a flow mailed out.
+type MemoryMailer struct {
+	mu   sync.Mutex
+	sent []Message
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (m *MemoryMailer) Send(msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// Sent returns every message sent so far, oldest first.
+func (m *MemoryMailer) Sent() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sent := make([]Message, len(m.sent))
+	copy(sent, m.sent)
+	return sent
+}
+
+// Last returns the most recent message sent to the given address.
+func (m *MemoryMailer) Last(to string) (Message, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := len(m.sent) - 1; i >= 0; i-- {
+		if m.sent[i].To == to {
+			return m.sent[i], true
+		}
+	}
+	return Message{}, false
+}
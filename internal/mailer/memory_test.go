@@ -0,0 +1,26 @@
+package mailer
+
+import "testing"
+
+func TestMemoryMailer(t *testing.T) {
+	m := NewMemoryMailer()
+	for _, msg := range []Message{
+		{To: "a@example.com", Subject: "first"},
+		{To: "b@example.com", Subject: "other"},
+		{To: "a@example.com", Subject: "second"},
+	} {
+		if err := m.Send(msg); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+
+	if sent := m.Sent(); len(sent) != 3 || sent[0].Subject != "first" {
+		t.Errorf("Sent = %+v, want 3 messages oldest first", sent)
+	}
+	if last, ok := m.Last("a@example.com"); !ok || last.Subject != "second" {
+		t.Errorf("Last(a) = %+v, %v, want the second message", last, ok)
+	}
+	if _, ok := m.Last("nobody@example.com"); ok {
+		t.Error("Last found a message for an address that got none")
+	}
+}
@@ -0,0 +1,57 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+
+	"github.com/sudo-hassan-zahid/go-api-server/internal/config"
+)
+
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+func NewSMTPMailer(cfg config.MailConfig) *SMTPMailer {
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+
+	return &SMTPMailer{
+		addr: net.JoinHostPort(cfg.Host, cfg.Port),
+		from: cfg.From,
+		auth: auth,
+	}
+}
+
+func (m *SMTPMailer) Send(msg Message) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", m.from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(msg.Body)
+
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{msg.To}, []byte(b.String()))
+}
+
+// Check dials the SMTP server, for the readiness probe.
+func (m *SMTPMailer) Check(ctx context.Context) (interface{}, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"addr": m.addr}, conn.Close()
+}
+
+func (m *SMTPMailer) Name() string {
+	return "mailer"
+}
@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// unmatchedRoute labels requests that never reached a route handler, either
+// because no route matched or a middleware answered first, so scanners
+// probing random paths cannot blow up the label cardinality.
+const unmatchedRoute = "unmatched"
+
+// Middleware records the count and latency of every request. Requests are
+// labelled with the route template (/api/users/:id), never the raw path.
+func Middleware() fiber.Handler {
+	// Routes are registered after the middleware, so the set of handler
+	// routes is built on the first request.
+	var (
+		once   sync.Once
+		routes map[string]bool
+	)
+	return func(c *fiber.Ctx) error {
+		once.Do(func() {
+			routes = map[string]bool{}
+			for _, r := range c.App().GetRoutes(true) {
+				routes[r.Method+" "+r.Path] = true
+			}
+		})
+
+		start := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			if e, ok := err.(*fiber.Error); ok {
+				status = e.Code
+			} else {
+				status = fiber.StatusInternalServerError
+			}
+		}
+
+		route := c.Route().Path
+		if !routes[c.Route().Method+" "+route] {
+			route = unmatchedRoute
+		}
+
+		labels := []string{route, c.Method(), strconv.Itoa(status)}
+		HTTPRequests.WithLabelValues(labels...).Inc()
+		HTTPDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// Handler serves the registry in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// FiberHandler serves the registry from a Fiber route.
+func FiberHandler() fiber.Handler {
+	return adaptor.HTTPHandler(Handler())
+}
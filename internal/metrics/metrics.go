@@ -0,0 +1,84 @@
+// Package metrics holds the Prometheus collectors of the server. Collectors
+// are always updated, which is cheap; whether they are exposed at /metrics
+// is decided by METRICS_ENABLED.
+package metrics
+
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "go_api_server"
+
+// Results of a login attempt, for LoginAttempts.
+const (
+	LoginOK          = "ok"
+	LoginFailed      = "failed"
+	LoginUnverified  = "unverified"
+	LoginMFARequired = "mfa_required"
+	LoginError       = "error"
+)
+
+// Registry holds every collector below plus the Go runtime and process
+// collectors.
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route template, method and status.",
+	}, []string{"route", "method", "status"})
+
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route template, method and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "GORM query latency by operation and table.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table"})
+
+	LoginAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_logins_total",
+		Help:      "Login attempts by result.",
+	}, []string{"result"})
+
+	TokensIssued = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_tokens_issued_total",
+		Help:      "JWTs signed, by token_use.",
+	}, []string{"token_use"})
+
+	JWTRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_jwt_rejections_total",
+		Help:      "Access tokens refused by the JWT middleware, by reason.",
+	}, []string{"reason"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		DBQueryDuration,
+		LoginAttempts,
+		TokensIssued,
+		JWTRejections,
+	)
+}
+
+// RegisterDBStats exposes the sql.DBStats of the connection pool.
+func RegisterDBStats(db *sql.DB, name string) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, name))
+}
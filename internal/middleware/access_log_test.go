@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSensitiveParam(t *testing.T) {
+	jwt := "eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.c2ln"
+
+	for _, tc := range []struct {
+		key, value string
+		want       bool
+	}{
+		{"token", "abc", true},
+		{"access_token", "abc", true},
+		{"RefreshToken", "abc", true},
+		{"client_secret", "abc", true},
+		{"password", "abc", true},
+		{"X-Amz-Signature", "abc", true},
+		{"code", "123456", true},
+		{"sig", "abc", true},
+		{"jwt", "abc", true},
+		{"auth", "abc", true},
+		{"Authorization", "abc", true},
+		{"api_key", "abc", true},
+		{"apikey", "abc", true},
+		{"next", jwt, true},
+		{"page", "2", false},
+		{"q", "alice", false},
+		{"sort", "-created_at", false},
+		{"keyword", "go", false},
+		{"cursor", "eyJ0Ijo.abc", false},
+		{"codes", "abc", false},
+	} {
+		if got := sensitiveParam(tc.key, tc.value); got != tc.want {
+			t.Errorf("sensitiveParam(%q, %q) = %v, want %v", tc.key, tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestRedactedURL(t *testing.T) {
+	app := fiber.New()
+	app.Get("/*", func(c *fiber.Ctx) error { return c.SendString(redactedURL(c)) })
+
+	for _, tc := range []struct {
+		uri, want string
+	}{
+		{"/api/users", "/api/users"},
+		{"/api/users?page=2&sort=-created_at", "/api/users?page=2&sort=-created_at"},
+		{"/verify?token=abc123", "/verify?token=" + redacted},
+		{"/reset?email=a%40b.c&reset_token=abc", "/reset?email=a%40b.c&reset_token=" + redacted},
+		{"/cb?code=123&state=xyz", "/cb?code=" + redacted + "&state=xyz"},
+		{"/x?next=eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.c2ln", "/x?next=" + redacted},
+	} {
+		if got := serve(t, app, "203.0.113.7", tc.uri, nil); got != tc.want {
+			t.Errorf("redactedURL(%s) = %s, want %s", tc.uri, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net/netip"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ResolveClientIP resolves the client IP once per request and stores it for
+// ClientIP. When the connection comes from one of trustedProxies (IPs or CIDR
+// ranges), X-Forwarded-For is walked from the right and the first hop that is
+// not a trusted proxy wins. Entries to the left of it are written by the
+// client and are ignored, so they cannot spoof the IP used by logs and the
+// rate limiter.
+func ResolveClientIP(trustedProxies []string) fiber.Handler {
+	trusted := make([]netip.Prefix, 0, len(trustedProxies))
+	for _, proxy := range trustedProxies {
+		if prefix, err := netip.ParsePrefix(proxy); err == nil {
+			trusted = append(trusted, prefix.Masked())
+		} else if addr, err := netip.ParseAddr(proxy); err == nil {
+			trusted = append(trusted, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+		}
+	}
+
+	isTrusted := func(addr netip.Addr) bool {
+		for _, prefix := range trusted {
+			if prefix.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(c *fiber.Ctx) error {
+		c.Locals("clientIP", resolveClientIP(c, isTrusted))
+		return c.Next()
+	}
+}
+
+func resolveClientIP(c *fiber.Ctx, isTrusted func(netip.Addr) bool) string {
+	remote, ok := netip.AddrFromSlice(c.Context().RemoteIP())
+	if !ok {
+		return c.IP()
+	}
+	client := remote.Unmap()
+	if !isTrusted(client) {
+		return client.String()
+	}
+
+	var hops []string
+	for _, header := range c.Request().Header.PeekAll(fiber.HeaderXForwardedFor) {
+		hops = append(hops, strings.Split(string(header), ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			// Whatever is left of a malformed entry cannot be trusted either.
+			break
+		}
+		client = addr.Unmap()
+		if !isTrusted(client) {
+			break
+		}
+	}
+	return client.String()
+}
+
+// ClientIP returns the IP resolved by ResolveClientIP, or the peer address of
+// the connection when that middleware did not run.
+func ClientIP(c *fiber.Ctx) string {
+	if ip, ok := c.Locals("clientIP").(string); ok {
+		return ip
+	}
+	return c.IP()
+}
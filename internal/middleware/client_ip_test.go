@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"net"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// serve runs one GET request from remoteIP through app and returns the body.
+func serve(t *testing.T, app *fiber.App, remoteIP, uri string, headers map[string][]string) string {
+	t.Helper()
+
+	var req fasthttp.Request
+	req.Header.SetMethod(fiber.MethodGet)
+	req.SetRequestURI(uri)
+	for key, values := range headers {
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+
+	var ctx fasthttp.RequestCtx
+	ctx.Init(&req, &net.TCPAddr{IP: net.ParseIP(remoteIP), Port: 40000}, nil)
+	app.Handler()(&ctx)
+	if status := ctx.Response.StatusCode(); status != fiber.StatusOK {
+		t.Fatalf("GET %s: status %d", uri, status)
+	}
+	return string(ctx.Response.Body())
+}
+
+func TestResolveClientIP(t *testing.T) {
+	app := fiber.New()
+	app.Use(ResolveClientIP([]string{"10.0.0.0/8", "192.0.2.1", "2001:db8::/32", "not-an-ip"}))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendString(ClientIP(c)) })
+
+	for _, tc := range []struct {
+		name   string
+		remote string
+		xff    []string
+		want   string
+	}{
+		{"direct client", "203.0.113.7", nil, "203.0.113.7"},
+		{"untrusted peer ignores XFF", "203.0.113.7", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"trusted proxy without XFF", "10.1.2.3", nil, "10.1.2.3"},
+		{"trusted proxy", "10.1.2.3", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"trusted single IP", "192.0.2.1", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"spoofed leftmost hop", "10.1.2.3", []string{"1.1.1.1, 198.51.100.1"}, "198.51.100.1"},
+		{"chain of trusted proxies", "10.1.2.3", []string{"1.1.1.1, 198.51.100.1, 10.9.9.9, 192.0.2.1"}, "198.51.100.1"},
+		{"repeated headers", "10.1.2.3", []string{"1.1.1.1", "198.51.100.1, 10.9.9.9"}, "198.51.100.1"},
+		{"all hops trusted", "10.1.2.3", []string{"10.0.0.1, 10.0.0.2"}, "10.0.0.1"},
+		{"malformed hop stops the walk", "10.1.2.3", []string{"1.1.1.1, garbage, 10.9.9.9"}, "10.9.9.9"},
+		{"spaces around hops", "10.1.2.3", []string{" 198.51.100.1 ,10.9.9.9 "}, "198.51.100.1"},
+		{"IPv4-mapped IPv6 hop", "10.1.2.3", []string{"::ffff:198.51.100.1"}, "198.51.100.1"},
+		{"IPv6 proxy", "2001:db8::1", []string{"2001:db9::5"}, "2001:db9::5"},
+		{"malformed rightmost hop", "10.1.2.3", []string{"198.51.100.1, not-an-ip"}, "10.1.2.3"},
+	} {
+		headers := map[string][]string{}
+		if tc.xff != nil {
+			headers[fiber.HeaderXForwardedFor] = tc.xff
+		}
+		if got := serve(t, app, tc.remote, "/", headers); got != tc.want {
+			t.Errorf("%s: client IP %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestClientIPWithoutMiddleware(t *testing.T) {
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendString(ClientIP(c)) })
+
+	headers := map[string][]string{fiber.HeaderXForwardedFor: {"198.51.100.1"}}
+	if got := serve(t, app, "203.0.113.7", "/", headers); got != "203.0.113.7" {
+		t.Errorf("client IP %s, want the peer address 203.0.113.7", got)
+	}
+}
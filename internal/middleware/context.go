@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequestContext gives every request a context that ends after timeout, when
+// base is cancelled or when the handler returns, whichever comes first.
+// Handlers pass c.UserContext() down to services so the request's queries
+// stop with it. fasthttp does not report client disconnects, so the deadline
+// is what bounds a request whose client has gone away.
+func RequestContext(base context.Context, timeout time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var ctx context.Context
+		var cancel context.CancelFunc
+		if timeout > 0 {
+			ctx, cancel = context.WithTimeout(c.UserContext(), timeout)
+		} else {
+			ctx, cancel = context.WithCancel(c.UserContext())
+		}
+		defer cancel()
+
+		stop := context.AfterFunc(base, cancel)
+		defer stop()
+
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
@@ -6,27 +6,32 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/sudo-hassan-zahid/go-api-server/internal/auth"
 	appErrors "github.com/sudo-hassan-zahid/go-api-server/internal/errors"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/metrics"
 )
 
 func JWTMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
+			metrics.JWTRejections.WithLabelValues("missing").Inc()
 			return appErrors.HandleError(c, appErrors.ErrUnauthorized)
 		}
 
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
+			metrics.JWTRejections.WithLabelValues("malformed_header").Inc()
 			return appErrors.HandleError(c, appErrors.ErrUnauthorized)
 		}
 
-		claims, err := auth.ValidateToken(parts[1])
+		claims, err := auth.ValidateToken(c.UserContext(), parts[1], auth.TokenUseAccess)
 		if err != nil {
+			metrics.JWTRejections.WithLabelValues(auth.RejectionReason(err)).Inc()
 			return appErrors.HandleError(c, appErrors.ErrUnauthorized)
 		}
 
-		c.Locals("userID", claims.UserID)
+		c.Locals("userID", claims.Subject)
 		c.Locals("role", claims.Role)
+		c.Locals("claims", claims)
 
 		return c.Next()
 	}
@@ -18,14 +18,20 @@ func ErrorLogger() fiber.Handler {
 			var status int
 			var message string
 
+			// The cause is logged; the client only learns it timed out.
+			cause := err
+			if appErrors.IsTimeout(err) {
+				err = appErrors.ErrRequestTimeout
+			}
+
 			switch err {
-			case appErrors.ErrBadRequest:
+			case appErrors.ErrBadRequest, appErrors.ErrMFAAlreadyEnabled, appErrors.ErrMFANotEnrolled:
 				status = fiber.StatusBadRequest
 				message = err.Error()
 			case appErrors.ErrUnauthorized:
 				status = fiber.StatusUnauthorized
 				message = err.Error()
-			case appErrors.ErrForbidden:
+			case appErrors.ErrForbidden, appErrors.ErrEmailNotVerified:
 				status = fiber.StatusForbidden
 				message = err.Error()
 			case appErrors.ErrInvalidCredentials:
@@ -34,23 +40,32 @@ func ErrorLogger() fiber.Handler {
 			case appErrors.ErrEmailAlreadyExists:
 				status = fiber.StatusBadRequest
 				message = err.Error()
+			case appErrors.ErrTokenInvalid, appErrors.ErrTokenReused, appErrors.ErrInvalidMFACode:
+				status = fiber.StatusUnauthorized
+				message = err.Error()
 			case appErrors.ErrUserNotFound:
 				status = fiber.StatusNotFound
 				message = err.Error()
+			case appErrors.ErrTooManyRequests:
+				status = fiber.StatusTooManyRequests
+				message = err.Error()
+			case appErrors.ErrRequestTimeout:
+				status = fiber.StatusServiceUnavailable
+				message = err.Error()
 			default:
 				status = fiber.StatusInternalServerError
 				message = appErrors.ErrInternalServer.Error()
 			}
 
-			appLogger.Log.Error().
-				Err(err).
+			appLogger.FromRequest(c).Error().
+				Err(cause).
 				Str("method", c.Method()).
-				Str("url", c.OriginalURL()).
+				Str("url", redactedURL(c)).
 				Int("status", status).
 				Dur("latency", time.Since(start)).
 				Msg("Request failed")
 
-			return c.Status(status).JSON(fiber.Map{"error": message})
+			return appErrors.SendError(c, status, message)
 		}
 
 		return nil
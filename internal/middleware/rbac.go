@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/auth"
+	appErrors "github.com/sudo-hassan-zahid/go-api-server/internal/errors"
+)
+
+// RequireRole lets the request through if the caller has any of roles.
+// It must run after JWTMiddleware.
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, _ := c.Locals("role").(string)
+		for _, r := range roles {
+			if role == r {
+				return c.Next()
+			}
+		}
+		return appErrors.HandleError(c, appErrors.ErrForbidden)
+	}
+}
+
+// RequirePermission lets the request through if the caller's role grants all
+// of permissions. It must run after JWTMiddleware.
+func RequirePermission(permissions ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, _ := c.Locals("role").(string)
+		for _, perm := range permissions {
+			if !auth.HasPermission(role, perm) {
+				return appErrors.HandleError(c, appErrors.ErrForbidden)
+			}
+		}
+		return c.Next()
+	}
+}
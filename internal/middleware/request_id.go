@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	appLogger "github.com/sudo-hassan-zahid/go-api-server/internal/logger"
+)
+
+const maxRequestIDLength = 128
+
+// RequestID reuses the caller's X-Request-ID, or generates one, and echoes it
+// in the response. The request context gets a child of the global logger
+// carrying the ID; see logger.FromRequest.
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(fiber.HeaderXRequestID)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		c.Set(fiber.HeaderXRequestID, id)
+		c.Locals("requestID", id)
+
+		log := appLogger.Log.With().Str("request_id", id).Logger()
+		c.SetUserContext(appLogger.WithContext(c.UserContext(), log))
+		return c.Next()
+	}
+}
+
+// validRequestID accepts short IDs made of characters that are safe to copy
+// into logs and headers; anything else is replaced.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+	appErrors "github.com/sudo-hassan-zahid/go-api-server/internal/errors"
+)
+
+// Swappable is a middleware whose implementation can be replaced while the
+// server runs, for settings that change on config reload.
+type Swappable struct {
+	handler atomic.Pointer[fiber.Handler]
+}
+
+func (s *Swappable) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return (*s.handler.Load())(c)
+	}
+}
+
+func (s *Swappable) set(h fiber.Handler) {
+	s.handler.Store(&h)
+}
+
+// CORS allows the given origins; "*" allows any.
+type CORS struct{ Swappable }
+
+func NewCORS(origins []string) *CORS {
+	c := &CORS{}
+	c.Update(origins)
+	return c
+}
+
+func (c *CORS) Update(origins []string) {
+	c.set(cors.New(cors.Config{
+		AllowOrigins:  strings.Join(origins, ","),
+		AllowMethods:  "GET,POST,PUT,PATCH,DELETE,OPTIONS",
+		AllowHeaders:  "Origin, Content-Type, Accept, Authorization, X-Request-ID",
+		ExposeHeaders: "X-Request-ID",
+	}))
+}
+
+// RateLimiter allows max requests per client IP within window. A max of 0
+// turns it off. Updating the limits starts new counting windows.
+type RateLimiter struct{ Swappable }
+
+func NewRateLimiter(max int, window time.Duration) *RateLimiter {
+	r := &RateLimiter{}
+	r.Update(max, window)
+	return r
+}
+
+func (r *RateLimiter) Update(max int, window time.Duration) {
+	if max <= 0 {
+		r.set(func(c *fiber.Ctx) error { return c.Next() })
+		return
+	}
+	r.set(limiter.New(limiter.Config{
+		Max:          max,
+		Expiration:   window,
+		KeyGenerator: ClientIP,
+		LimitReached: func(c *fiber.Ctx) error {
+			return appErrors.HandleError(c, appErrors.ErrTooManyRequests)
+		},
+	}))
+}
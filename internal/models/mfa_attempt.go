@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MFAAttempt counts wrong two-factor codes. JTI is either the mfa_pending
+// token the codes were submitted with, and the row expires with it, or
+// "user:<id>" for codes entered while signed in, and the row expires with the
+// lockout window.
+type MFAAttempt struct {
+	JTI       string    `gorm:"primaryKey" json:"jti"`
+	UserID    uuid.UUID `gorm:"type:uuid;index;not null" json:"user_id"`
+	Failures  int       `gorm:"not null;default:0" json:"failures"`
+	ExpiresAt time.Time `gorm:"index;not null" json:"expires_at"`
+}
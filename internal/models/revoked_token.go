@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RevokedToken struct {
+	JTI       string    `gorm:"primaryKey" json:"jti"`
+	UserID    uuid.UUID `gorm:"type:uuid;index;not null" json:"user_id"`
+	ExpiresAt time.Time `gorm:"index;not null" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UserTokenRevocation invalidates every token of a user issued before
+// RevokedBefore. It can be dropped once ExpiresAt passes, because by then
+// every token it covers has expired on its own.
+type UserTokenRevocation struct {
+	UserID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	RevokedBefore time.Time `gorm:"not null" json:"revoked_before"`
+	ExpiresAt     time.Time `gorm:"index;not null" json:"expires_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
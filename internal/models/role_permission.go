@@ -0,0 +1,8 @@
+package models
+
+// RolePermission grants a permission to a role. Rows here override the
+// permissions configured for the same role.
+type RolePermission struct {
+	Role       string `gorm:"primaryKey" json:"role"`
+	Permission string `gorm:"primaryKey" json:"permission"`
+}
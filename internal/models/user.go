@@ -9,16 +9,19 @@ import (
 )
 
 type User struct {
-	ID         uuid.UUID      `gorm:"type:uuid;primaryKey" json:"id"`
-	Email      string         `gorm:"uniqueIndex;not null" json:"email"`
-	Password   string         `gorm:"not null" json:"-"`
-	FirstName  string         `gorm:"index:idx_name;not null" json:"first_name"`
-	LastName   string         `gorm:"index:idx_name;not null" json:"last_name"`
-	IsVerified bool           `gorm:"default:false" json:"is_verified"`
-	Role       string         `gorm:"default:'user'" json:"role"`
-	CreatedAt  time.Time      `json:"created_at"`
-	UpdatedAt  time.Time      `json:"updated_at"`
-	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+	ID           uuid.UUID      `gorm:"type:uuid;primaryKey" json:"id"`
+	Email        string         `gorm:"uniqueIndex;not null" json:"email"`
+	Password     string         `gorm:"not null" json:"-"`
+	FirstName    string         `gorm:"index:idx_name;not null" json:"first_name"`
+	LastName     string         `gorm:"index:idx_name;not null" json:"last_name"`
+	IsVerified   bool           `gorm:"default:false" json:"is_verified"`
+	Role         string         `gorm:"default:'user'" json:"role"`
+	TOTPSecret   string         `json:"-"`
+	TOTPEnabled  bool           `gorm:"default:false" json:"mfa_enabled"`
+	TOTPLastStep int64          `gorm:"default:0" json:"-"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
@@ -29,9 +32,37 @@ func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
 	return
 }
 
+// BeforeUpdate hashes a new password. Update hooks run against the model,
+// which still holds the old hash, so the plain password is read from and
+// written back to the update destination.
 func (u *User) BeforeUpdate(tx *gorm.DB) (err error) {
-	if tx.Statement.Changed("Password") {
-		u.Password, err = utils.HashPassword(u.Password)
+	if !tx.Statement.Changed("Password") {
+		return nil
 	}
-	return
+
+	var plain string
+	switch dest := tx.Statement.Dest.(type) {
+	case map[string]interface{}:
+		for _, key := range []string{"Password", "password"} {
+			if plain, ok := dest[key].(string); ok {
+				if dest[key], err = utils.HashPassword(plain); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	case *User:
+		plain = dest.Password
+	case User:
+		plain = dest.Password
+	default:
+		return nil
+	}
+
+	hashed, err := utils.HashPassword(plain)
+	if err != nil {
+		return err
+	}
+	tx.Statement.SetColumn("Password", hashed)
+	return nil
 }
@@ -1,12 +1,24 @@
 package repository
 
 import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
 	"github.com/sudo-hassan-zahid/go-api-server/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type AuthRepository interface {
-	Create(user *models.User) error
+	CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error
+	GetRefreshToken(ctx context.Context, id uuid.UUID) (*models.RefreshToken, error)
+	MarkRefreshTokenUsed(ctx context.Context, id uuid.UUID) (bool, error)
+	RevokeTokenFamily(ctx context.Context, familyID uuid.UUID) error
+	RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error
+	CreateOneTimeToken(ctx context.Context, token *models.OneTimeToken) error
+	ConsumeOneTimeToken(ctx context.Context, hash, purpose string) (*models.OneTimeToken, error)
+	InvalidateOneTimeTokens(ctx context.Context, userID uuid.UUID, purpose string) error
 }
 
 type authRepo struct {
@@ -17,6 +29,67 @@ func NewAuthRepository(db *gorm.DB) AuthRepository {
 	return &authRepo{db: db}
 }
 
-func (r *authRepo) Create(user *models.User) error {
-	return r.db.Create(user).Error
+func (r *authRepo) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *authRepo) GetRefreshToken(ctx context.Context, id uuid.UUID) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	if err := r.db.WithContext(ctx).First(&token, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkRefreshTokenUsed atomically consumes a refresh token. It reports false
+// when the token was already used or revoked, so two concurrent refreshes
+// with the same token cannot both succeed.
+func (r *authRepo) MarkRefreshTokenUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *authRepo) RevokeTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *authRepo) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *authRepo) CreateOneTimeToken(ctx context.Context, token *models.OneTimeToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+// ConsumeOneTimeToken marks a live token as used and returns it. A token that
+// is unknown, expired or already used yields gorm.ErrRecordNotFound.
+func (r *authRepo) ConsumeOneTimeToken(ctx context.Context, hash, purpose string) (*models.OneTimeToken, error) {
+	var token models.OneTimeToken
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&token).
+		Clauses(clause.Returning{}).
+		Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", hash, purpose, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &token, nil
+}
+
+func (r *authRepo) InvalidateOneTimeTokens(ctx context.Context, userID uuid.UUID, purpose string) error {
+	return r.db.WithContext(ctx).Model(&models.OneTimeToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", time.Now()).Error
 }
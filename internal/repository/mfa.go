@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MFARepository interface {
+	SetTOTPSecret(ctx context.Context, userID uuid.UUID, secret string) error
+	EnableTOTP(ctx context.Context, userID uuid.UUID, codeHashes []string) error
+	DisableTOTP(ctx context.Context, userID uuid.UUID) error
+	AdvanceTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error)
+	ConsumeRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error)
+	RecordFailedAttempt(ctx context.Context, key string, userID uuid.UUID, expiresAt time.Time) (int, error)
+	ClearFailedAttempts(ctx context.Context, key string) error
+}
+
+type mfaRepo struct {
+	db *gorm.DB
+}
+
+func NewMFARepository(db *gorm.DB) MFARepository {
+	return &mfaRepo{db: db}
+}
+
+func (r *mfaRepo) SetTOTPSecret(ctx context.Context, userID uuid.UUID, secret string) error {
+	return r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"totp_secret":    secret,
+		"totp_enabled":   false,
+		"totp_last_step": 0,
+	}).Error
+}
+
+// EnableTOTP turns two-factor authentication on and replaces the user's
+// recovery codes in a single transaction.
+func (r *mfaRepo) EnableTOTP(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).Update("totp_enabled", true).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		codes := make([]models.RecoveryCode, len(codeHashes))
+		for i, hash := range codeHashes {
+			codes[i] = models.RecoveryCode{ID: uuid.New(), UserID: userID, CodeHash: hash}
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (r *mfaRepo) DisableTOTP(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"totp_secret":    "",
+			"totp_enabled":   false,
+			"totp_last_step": 0,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+	})
+}
+
+// AdvanceTOTPStep records the time step of an accepted code. It reports false
+// when that step, or a later one, was already used, which stops a code from
+// being replayed within its validity window.
+func (r *mfaRepo) AdvanceTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", userID, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *mfaRepo) ConsumeRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RecordFailedAttempt counts a wrong code against key and returns the number
+// of failures so far. key is the jti of an mfa_pending token, or a per-user
+// key for codes entered while signed in. The count starts over once
+// expiresAt has passed. The increment is a single upsert, so concurrent
+// guesses cannot slip past the limit.
+func (r *mfaRepo) RecordFailedAttempt(ctx context.Context, key string, userID uuid.UUID, expiresAt time.Time) (int, error) {
+	attempt := models.MFAAttempt{JTI: key, UserID: userID, Failures: 1, ExpiresAt: expiresAt}
+	err := r.db.WithContext(ctx).Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "jti"}},
+			DoUpdates: clause.Set{
+				{Column: clause.Column{Name: "failures"}, Value: gorm.Expr("CASE WHEN mfa_attempts.expires_at <= now() THEN 1 ELSE mfa_attempts.failures + 1 END")},
+				{Column: clause.Column{Name: "expires_at"}, Value: gorm.Expr("CASE WHEN mfa_attempts.expires_at <= now() THEN excluded.expires_at ELSE mfa_attempts.expires_at END")},
+			},
+		},
+		clause.Returning{Columns: []clause.Column{{Name: "failures"}}},
+	).Create(&attempt).Error
+	return attempt.Failures, err
+}
+
+func (r *mfaRepo) ClearFailedAttempts(ctx context.Context, key string) error {
+	return r.db.WithContext(ctx).Where("jti = ?", key).Delete(&models.MFAAttempt{}).Error
+}
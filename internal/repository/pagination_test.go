@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	SetCursorSecret([]byte("test-secret"))
+
+	want := Cursor{CreatedAt: time.Date(2025, 1, 31, 12, 0, 0, 123456000, time.UTC), ID: uuid.New(), Sort: "-created_at"}
+	got, err := DecodeCursor(want.Encode(), "-created_at")
+	if err != nil {
+		t.Fatalf("DecodeCursor: %v", err)
+	}
+	if !got.CreatedAt.Equal(want.CreatedAt) || got.ID != want.ID || got.Sort != want.Sort {
+		t.Errorf("DecodeCursor = %+v, want %+v", *got, want)
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	SetCursorSecret([]byte("test-secret"))
+
+	cursor := Cursor{CreatedAt: time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC), ID: uuid.New(), Sort: "-created_at"}
+	valid := cursor.Encode()
+	encoded, sig, _ := strings.Cut(valid, ".")
+
+	// forged has a well-formed payload pointing elsewhere, but carries the
+	// signature of the valid cursor.
+	forgedPayload, _ := json.Marshal(Cursor{CreatedAt: cursor.CreatedAt.Add(-time.Hour), ID: cursor.ID, Sort: cursor.Sort})
+	forged := base64.RawURLEncoding.EncodeToString(forgedPayload) + "." + sig
+
+	SetCursorSecret([]byte("another-secret"))
+	otherKey := cursor.Encode()
+	SetCursorSecret([]byte("test-secret"))
+
+	flipped := []byte(sig)
+	if flipped[0] == 'A' {
+		flipped[0] = 'B'
+	} else {
+		flipped[0] = 'A'
+	}
+
+	for _, tc := range []struct {
+		name   string
+		cursor string
+		sort   string
+	}{
+		{"empty", "", "-created_at"},
+		{"no signature", encoded, "-created_at"},
+		{"empty signature", encoded + ".", "-created_at"},
+		{"tampered signature", encoded + "." + string(flipped), "-created_at"},
+		{"truncated signature", valid[:len(valid)-2], "-created_at"},
+		{"truncated payload", encoded[2:] + "." + sig, "-created_at"},
+		{"tampered payload", forged, "-created_at"},
+		{"signed with another key", otherKey, "-created_at"},
+		{"another sort", valid, "created_at"},
+		{"another sort column", valid, "-updated_at"},
+	} {
+		if _, err := DecodeCursor(tc.cursor, tc.sort); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: DecodeCursor = %v, want ErrInvalidCursor", tc.name, err)
+		}
+	}
+}
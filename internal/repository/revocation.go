@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RevocationRepository interface {
+	Revoke(ctx context.Context, jti string, userID uuid.UUID, expiresAt time.Time) error
+	RevokeAllForUser(ctx context.Context, userID uuid.UUID, before, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, jti, userID string, issuedAt time.Time) (bool, error)
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+type revocationRepo struct {
+	db *gorm.DB
+}
+
+func NewRevocationRepository(db *gorm.DB) RevocationRepository {
+	return &revocationRepo{db: db}
+}
+
+func (r *revocationRepo) Revoke(ctx context.Context, jti string, userID uuid.UUID, expiresAt time.Time) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&models.RevokedToken{
+		JTI:       jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}).Error
+}
+
+func (r *revocationRepo) RevokeAllForUser(ctx context.Context, userID uuid.UUID, before, expiresAt time.Time) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"revoked_before", "expires_at", "updated_at"}),
+	}).Create(&models.UserTokenRevocation{
+		UserID:        userID,
+		RevokedBefore: before,
+		ExpiresAt:     expiresAt,
+	}).Error
+}
+
+func (r *revocationRepo) IsRevoked(ctx context.Context, jti, userID string, issuedAt time.Time) (bool, error) {
+	var revoked bool
+	err := r.db.WithContext(ctx).Raw(
+		`SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = ?)
+			OR EXISTS (SELECT 1 FROM user_token_revocations WHERE user_id = ? AND revoked_before > ?)`,
+		jti, userID, issuedAt,
+	).Scan(&revoked).Error
+	return revoked, err
+}
+
+func (r *revocationRepo) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	var deleted int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("expires_at < ?", now).Delete(&models.RevokedToken{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted += result.RowsAffected
+
+		result = tx.Where("expires_at < ?", now).Delete(&models.UserTokenRevocation{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted += result.RowsAffected
+
+		result = tx.Where("expires_at < ?", now).Delete(&models.RefreshToken{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted += result.RowsAffected
+
+		result = tx.Where("expires_at < ?", now).Delete(&models.MFAAttempt{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted += result.RowsAffected
+		return nil
+	})
+	return deleted, err
+}
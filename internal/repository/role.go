@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/models"
+	"gorm.io/gorm"
+)
+
+type RoleRepository interface {
+	ListRolePermissions(ctx context.Context) (map[string][]string, error)
+}
+
+type roleRepo struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepo{db: db}
+}
+
+func (r *roleRepo) ListRolePermissions(ctx context.Context) (map[string][]string, error) {
+	var rows []models.RolePermission
+	if err := r.db.WithContext(ctx).Order("role, permission").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	table := make(map[string][]string)
+	for _, row := range rows {
+		table[row.Role] = append(table[row.Role], row.Permission)
+	}
+	return table, nil
+}
@@ -1,15 +1,30 @@
 package repository
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
 	"github.com/sudo-hassan-zahid/go-api-server/internal/models"
 	"gorm.io/gorm"
 )
 
 type UserRepository interface {
-	Create(user *models.User) error
-	GetByEmail(email string) (*models.User, error)
-	GetByID(id uint) (*models.User, error)
-	GetAll() ([]models.User, error)
+	Create(ctx context.Context, user *models.User) error
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	List(ctx context.Context, filter UserFilter, opts UserListOptions) ([]models.User, int64, *Cursor, error)
+	EmailTaken(ctx context.Context, email string, excludeID uuid.UUID) (bool, error)
+	Update(ctx context.Context, user *models.User, fields map[string]interface{}) error
+	MarkVerified(ctx context.Context, id uuid.UUID) error
+	UpdatePassword(ctx context.Context, id uuid.UUID, password string) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	Restore(ctx context.Context, id uuid.UUID) error
+	Search(ctx context.Context, query string, limit int) ([]UserSearchResult, error)
 }
 
 type userRepo struct {
@@ -20,30 +35,242 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	return &userRepo{db: db}
 }
 
-func (r *userRepo) Create(user *models.User) error {
-	return r.db.Create(user).Error
+func (r *userRepo) Create(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
 }
 
-func (r *userRepo) GetByEmail(email string) (*models.User, error) {
+// GetByEmail matches email case-insensitively, as accounts created before
+// addresses were normalised may be stored in mixed case.
+func (r *userRepo) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	if err := r.db.Where("email = ?", email).First(&user).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("lower(email) = lower(?)", email).First(&user).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *userRepo) GetByID(id uint) (*models.User, error) {
+func (r *userRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
 	var user models.User
-	if err := r.db.First(&user, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).First(&user, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *userRepo) GetAll() ([]models.User, error) {
+type UserFilter struct {
+	Role         string
+	IsVerified   *bool
+	CreatedAfter *time.Time
+	EmailPrefix  string
+}
+
+// UserListOptions selects a page either by Offset or, for the created_at
+// sort only, by the After cursor.
+type UserListOptions struct {
+	Sort   string
+	Limit  int
+	Offset int
+	After  *Cursor
+}
+
+// UserSortColumns whitelists the sort keys accepted from callers. Only these
+// column names ever reach ORDER BY.
+var UserSortColumns = map[string]string{
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+	"email":      "email",
+	"first_name": "first_name",
+	"last_name":  "last_name",
+	"role":       "role",
+}
+
+const DefaultUserSort = "-created_at"
+
+var ErrInvalidSort = errors.New("invalid sort field")
+
+// ParseUserSort splits a sort parameter such as "-created_at" into a
+// whitelisted column and direction.
+func ParseUserSort(sort string) (column string, desc bool, err error) {
+	if sort == "" {
+		sort = DefaultUserSort
+	}
+	desc = strings.HasPrefix(sort, "-")
+	column, ok := UserSortColumns[strings.TrimPrefix(sort, "-")]
+	if !ok {
+		return "", false, ErrInvalidSort
+	}
+	return column, desc, nil
+}
+
+// List returns one page of users matching filter and the number of users
+// matching filter across all pages. When sorted by created_at the page is
+// read by keyset and the cursor of the next page is returned as well.
+func (r *userRepo) List(ctx context.Context, filter UserFilter, opts UserListOptions) ([]models.User, int64, *Cursor, error) {
+	column, desc, err := ParseUserSort(opts.Sort)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	query := r.db.WithContext(ctx).Model(&models.User{})
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	if filter.IsVerified != nil {
+		query = query.Where("is_verified = ?", *filter.IsVerified)
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where("created_at > ?", *filter.CreatedAfter)
+	}
+	if filter.EmailPrefix != "" {
+		query = query.Where("lower(email) LIKE ? ESCAPE '\\'", escapeLike(filter.EmailPrefix)+"%")
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, nil, err
+	}
+
+	if column == "created_at" {
+		if opts.After == nil {
+			query = query.Offset(opts.Offset)
+		}
+		keyset := Keyset{After: opts.After, Limit: opts.Limit, Desc: desc, Sort: opts.Sort}
+		if keyset.Sort == "" {
+			keyset.Sort = DefaultUserSort
+		}
+		users, next, err := FindPage(query, keyset, userCursor)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		return users, total, next, nil
+	}
+
+	if opts.After != nil {
+		return nil, 0, nil, ErrInvalidSort
+	}
+
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+
+	// id breaks ties so pages never overlap or skip rows.
 	var users []models.User
-	if err := r.db.Find(&users).Error; err != nil {
-		return nil, err
+	err = query.
+		Order(fmt.Sprintf("%s %s, id %s", column, direction, direction)).
+		Offset(opts.Offset).
+		Limit(opts.Limit).
+		Find(&users).Error
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	return users, total, nil, nil
+}
+
+func userCursor(u *models.User) Cursor {
+	return Cursor{CreatedAt: u.CreatedAt, ID: u.ID}
+}
+
+type UserSearchResult struct {
+	models.User
+	Rank float64 `json:"rank"`
+}
+
+// Search ranks users by full-text match on name and email, using prefix
+// matching on every term, and falls back to trigram similarity so partial
+// input and typos still find the account.
+func (r *userRepo) Search(ctx context.Context, query string, limit int) ([]UserSearchResult, error) {
+	pattern := "%" + escapeLike(query) + "%"
+	fullName := "(first_name || ' ' || last_name)"
+
+	rank := fmt.Sprintf("greatest(similarity(email, @q), similarity(%s, @q))", fullName)
+	match := fmt.Sprintf("email ILIKE @pattern OR %[1]s ILIKE @pattern OR email %% @q OR %[1]s %% @q", fullName)
+	args := map[string]interface{}{"q": query, "pattern": pattern}
+
+	if tsQuery := prefixTSQuery(query); tsQuery != "" {
+		rank = "ts_rank(search_vector, to_tsquery('simple', @tsq)) + " + rank
+		match = "search_vector @@ to_tsquery('simple', @tsq) OR " + match
+		args["tsq"] = tsQuery
+	}
+
+	var results []UserSearchResult
+	err := r.db.WithContext(ctx).Model(&models.User{}).
+		Select("users.*, "+rank+" AS rank", args).
+		Where(match, args).
+		Order("rank DESC, id").
+		Limit(limit).
+		Scan(&results).Error
+	return results, err
+}
+
+// prefixTSQuery turns free text into "term:* & term:*", keeping only letters
+// and digits so user input can never break the tsquery syntax.
+func prefixTSQuery(query string) string {
+	var terms []string
+	for _, word := range strings.Fields(query) {
+		term := strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return unicode.ToLower(r)
+			}
+			return -1
+		}, word)
+		if term != "" {
+			terms = append(terms, term+":*")
+		}
+	}
+	return strings.Join(terms, " & ")
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(s)
+}
+
+// EmailTaken compares case-insensitively, like GetByEmail, and also looks at
+// soft-deleted accounts, since they keep their row in the unique email index.
+func (r *userRepo) EmailTaken(ctx context.Context, email string, excludeID uuid.UUID) (bool, error) {
+	var taken bool
+	err := r.db.WithContext(ctx).Unscoped().Model(&models.User{}).
+		Select("count(*) > 0").
+		Where("lower(email) = lower(?) AND id <> ?", email, excludeID).
+		Find(&taken).Error
+	return taken, err
+}
+
+// Update writes fields, keyed by column name, to user. A "password" entry is
+// hashed by the BeforeUpdate hook.
+func (r *userRepo) Update(ctx context.Context, user *models.User, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(user).Updates(fields).Error
+}
+
+func (r *userRepo) MarkVerified(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Update("is_verified", true).Error
+}
+
+// UpdatePassword stores a new plain password, hashed by the BeforeUpdate hook.
+func (r *userRepo) UpdatePassword(ctx context.Context, id uuid.UUID, password string) error {
+	return r.db.WithContext(ctx).Model(&models.User{ID: id}).Update("password", password).Error
+}
+
+func (r *userRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Delete(&models.User{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *userRepo) Restore(ctx context.Context, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Unscoped().Model(&models.User{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
 	}
-	return users, nil
+	return nil
 }
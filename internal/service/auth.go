@@ -1,70 +1,510 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
 	"github.com/google/uuid"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/auth"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/config"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/constants"
 	appErrors "github.com/sudo-hassan-zahid/go-api-server/internal/errors"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/logger"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/mailer"
 	"github.com/sudo-hassan-zahid/go-api-server/internal/models"
 	"github.com/sudo-hassan-zahid/go-api-server/internal/repository"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/tracing"
 	"github.com/sudo-hassan-zahid/go-api-server/utils"
 	"gorm.io/gorm"
 )
 
 type AuthService interface {
-	CreateUser(email, password, firstName, lastName string) (*models.User, error)
-	LoginUser(email, password string) (*models.User, error)
+	CreateUser(ctx context.Context, email, password, firstName, lastName string) (*models.User, error)
+	LoginUser(ctx context.Context, email, password string) (*LoginResult, error)
+	IssueTokens(ctx context.Context, user *models.User) (*auth.TokenPair, error)
+	RefreshTokens(ctx context.Context, refreshToken string) (*models.User, *auth.TokenPair, error)
+	Logout(ctx context.Context, claims *auth.Claims, refreshToken string) error
+	LogoutAll(ctx context.Context, userID string) error
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context, email string) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, password string) error
+	ChangePassword(ctx context.Context, userID, currentPassword, newPassword string) (*models.User, *auth.TokenPair, error)
+	DeleteAccount(ctx context.Context, userID, password string) error
+}
+
+// LoginResult is the outcome of a password check. Tokens is set when the login
+// is complete; MFAToken instead when a two-factor code is still required.
+type LoginResult struct {
+	User     *models.User
+	Tokens   *auth.TokenPair
+	MFAToken string
 }
 
 type authService struct {
-	repo repository.AuthRepository
-	db   *gorm.DB
+	repo        repository.AuthRepository
+	users       repository.UserRepository
+	revocations repository.RevocationRepository
+	mailer      mailer.Mailer
+	cfg         config.AuthConfig
 }
 
-func NewAuthService(repo repository.AuthRepository, db *gorm.DB) AuthService {
-	return &authService{repo: repo, db: db}
+func NewAuthService(
+	repo repository.AuthRepository,
+	users repository.UserRepository,
+	revocations repository.RevocationRepository,
+	mail mailer.Mailer,
+	cfg config.AuthConfig,
+) AuthService {
+	return &authService{repo: repo, users: users, revocations: revocations, mailer: mail, cfg: cfg}
 }
 
-func (s *authService) CreateUser(email, password, firstName, lastName string) (*models.User, error) {
-	var exists bool
-	if err := s.db.Model(&models.User{}).Select("count(*) > 0").Where("email = ?", email).Find(&exists).Error; err != nil {
+func (s *authService) CreateUser(ctx context.Context, email, password, firstName, lastName string) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.CreateUser")
+	defer span.End()
+
+	email = utils.SanitizeEmail(email)
+
+	exists, err := s.users.EmailTaken(ctx, email, uuid.Nil)
+	if err != nil {
 		return nil, err
 	}
 	if exists {
 		return nil, appErrors.ErrEmailAlreadyExists
 	}
 
-	hashed, err := utils.HashPassword(password)
-	if err != nil {
-		return nil, err
-	}
-
+	// Password is hashed by the BeforeCreate hook.
 	user := &models.User{
 		ID:        uuid.New(),
 		Email:     email,
-		Password:  hashed,
+		Password:  password,
 		FirstName: firstName,
 		LastName:  lastName,
-		Role:      "user",
+		Role:      constants.ROLE_USER,
 	}
 
-	if err := s.db.Create(user).Error; err != nil {
+	if err := s.users.Create(ctx, user); err != nil {
 		return nil, err
 	}
 
+	// The account exists either way; a failed mail can be retried through
+	// the resend endpoint.
+	if err := s.sendVerification(ctx, user); err != nil {
+		logger.FromContext(ctx).Error().Err(err).Str("user_id", user.ID.String()).Msg("Failed to send verification email")
+	}
+
 	return user, nil
 }
 
-func (s *authService) LoginUser(email, password string) (*models.User, error) {
-	user := &models.User{}
-	if err := s.db.Where("email = ?", email).First(user).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+func (s *authService) LoginUser(ctx context.Context, email, password string) (*LoginResult, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.LoginUser")
+	defer span.End()
+
+	email = utils.SanitizeEmail(email)
+
+	user, err := s.users.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, appErrors.ErrInvalidCredentials
 		}
 		return nil, err
 	}
 
-	if !utils.CheckPassword(user.Password, password) {
+	_, hashSpan := tracing.Start(ctx, "bcrypt.CompareHashAndPassword")
+	ok := utils.CheckPassword(user.Password, password)
+	hashSpan.End()
+	if !ok {
 		return nil, appErrors.ErrInvalidCredentials
 	}
 
+	if s.cfg.RequireVerifiedEmail && !user.IsVerified {
+		return nil, appErrors.ErrEmailNotVerified
+	}
+
+	if user.TOTPEnabled {
+		mfaToken, err := auth.GenerateMFAPendingToken(user.ID.String())
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{User: user, MFAToken: mfaToken}, nil
+	}
+
+	tokens, err := s.IssueTokens(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{User: user, Tokens: tokens}, nil
+}
+
+func (s *authService) VerifyEmail(ctx context.Context, token string) error {
+	ctx, span := tracing.Start(ctx, "AuthService.VerifyEmail")
+	defer span.End()
+
+	record, err := s.repo.ConsumeOneTimeToken(ctx, auth.HashOpaqueToken(token), models.TokenPurposeEmailVerification)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return appErrors.ErrTokenInvalid
+		}
+		return err
+	}
+	return s.users.MarkVerified(ctx, record.UserID)
+}
+
+// ResendVerification issues a fresh verification token and invalidates the
+// previous ones. Unknown and already verified addresses are ignored so the
+// endpoint cannot be used to probe for accounts.
+func (s *authService) ResendVerification(ctx context.Context, email string) error {
+	ctx, span := tracing.Start(ctx, "AuthService.ResendVerification")
+	defer span.End()
+
+	user, err := s.users.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.IsVerified {
+		return nil
+	}
+
+	if err := s.repo.InvalidateOneTimeTokens(ctx, user.ID, models.TokenPurposeEmailVerification); err != nil {
+		return err
+	}
+	return s.sendVerification(ctx, user)
+}
+
+// ForgotPassword mails a password reset token to the account, if there is
+// one. The token is created and sent in the background so the response time
+// does not reveal whether the address is registered.
+func (s *authService) ForgotPassword(ctx context.Context, email string) error {
+	ctx, span := tracing.Start(ctx, "AuthService.ForgotPassword")
+	defer span.End()
+
+	user, err := s.users.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	// Detached from the request, which is over before the mail is sent.
+	bg := context.WithoutCancel(ctx)
+	go func() {
+		if err := s.sendPasswordReset(bg, user); err != nil {
+			logger.FromContext(bg).Error().Err(err).Str("user_id", user.ID.String()).Msg("Failed to send password reset email")
+		}
+	}()
+	return nil
+}
+
+// ResetPassword sets a new password using a reset token and revokes every
+// session of the account.
+func (s *authService) ResetPassword(ctx context.Context, token, password string) error {
+	ctx, span := tracing.Start(ctx, "AuthService.ResetPassword")
+	defer span.End()
+
+	record, err := s.repo.ConsumeOneTimeToken(ctx, auth.HashOpaqueToken(token), models.TokenPurposePasswordReset)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return appErrors.ErrTokenInvalid
+		}
+		return err
+	}
+
+	if err := s.users.UpdatePassword(ctx, record.UserID, password); err != nil {
+		return err
+	}
+	if err := s.repo.InvalidateOneTimeTokens(ctx, record.UserID, models.TokenPurposePasswordReset); err != nil {
+		return err
+	}
+	return s.revokeAllSessions(ctx, record.UserID)
+}
+
+// ChangePassword replaces the password after checking the current one. Every
+// session is revoked and a fresh token pair is returned for the caller, so
+// only the session that made the change stays logged in.
+func (s *authService) ChangePassword(ctx context.Context, userID, currentPassword, newPassword string) (*models.User, *auth.TokenPair, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.ChangePassword")
+	defer span.End()
+
+	user, err := s.checkPassword(ctx, userID, currentPassword)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.users.UpdatePassword(ctx, user.ID, newPassword); err != nil {
+		return nil, nil, err
+	}
+	if err := s.revokeAllSessions(ctx, user.ID); err != nil {
+		return nil, nil, err
+	}
+	if err := waitForNextSecond(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	tokens, err := s.IssueTokens(ctx, user)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, tokens, nil
+}
+
+// DeleteAccount soft deletes the caller's own account after checking the
+// password, and revokes all of its sessions.
+func (s *authService) DeleteAccount(ctx context.Context, userID, password string) error {
+	ctx, span := tracing.Start(ctx, "AuthService.DeleteAccount")
+	defer span.End()
+
+	user, err := s.checkPassword(ctx, userID, password)
+	if err != nil {
+		return err
+	}
+
+	if err := s.users.Delete(ctx, user.ID); err != nil {
+		return err
+	}
+	return s.revokeAllSessions(ctx, user.ID)
+}
+
+func (s *authService) checkPassword(ctx context.Context, userID, password string) (*models.User, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, appErrors.ErrUserNotFound
+	}
+
+	user, err := s.users.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	if !utils.CheckPassword(user.Password, password) {
+		return nil, appErrors.ErrInvalidCredentials
+	}
 	return user, nil
 }
+
+func (s *authService) sendPasswordReset(ctx context.Context, user *models.User) error {
+	if err := s.repo.InvalidateOneTimeTokens(ctx, user.ID, models.TokenPurposePasswordReset); err != nil {
+		return err
+	}
+
+	token, err := s.createOneTimeToken(ctx, user.ID, models.TokenPurposePasswordReset, s.cfg.ResetTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nUse the following token to reset your password:\n\n%s\n\n"+
+				"The token expires in %s. If you did not ask for a reset, you can ignore this email.\n",
+			user.FirstName, token, s.cfg.ResetTokenTTL,
+		),
+	})
+}
+
+func (s *authService) sendVerification(ctx context.Context, user *models.User) error {
+	token, err := s.createOneTimeToken(ctx, user.ID, models.TokenPurposeEmailVerification, s.cfg.VerificationTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nUse the following token to verify your email address:\n\n%s\n\nThe token expires in %s.\n",
+			user.FirstName, token, s.cfg.VerificationTokenTTL,
+		),
+	})
+}
+
+func (s *authService) createOneTimeToken(ctx context.Context, userID uuid.UUID, purpose string, ttl time.Duration) (string, error) {
+	token, hash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	record := &models.OneTimeToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := s.repo.CreateOneTimeToken(ctx, record); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *authService) IssueTokens(ctx context.Context, user *models.User) (*auth.TokenPair, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.IssueTokens")
+	defer span.End()
+
+	return s.issueTokens(ctx, user, uuid.New())
+}
+
+// RefreshTokens exchanges a refresh token for a new token pair in the same
+// family. Every refresh token is single use: presenting one that was already
+// exchanged revokes the whole family, since it means the token was copied.
+func (s *authService) RefreshTokens(ctx context.Context, refreshToken string) (*models.User, *auth.TokenPair, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.RefreshTokens")
+	defer span.End()
+
+	claims, err := auth.ValidateToken(ctx, refreshToken, auth.TokenUseRefresh)
+	if err != nil {
+		return nil, nil, appErrors.ErrTokenInvalid
+	}
+
+	tokenID, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return nil, nil, appErrors.ErrTokenInvalid
+	}
+	familyID, err := uuid.Parse(claims.FamilyID)
+	if err != nil {
+		return nil, nil, appErrors.ErrTokenInvalid
+	}
+
+	record, err := s.repo.GetRefreshToken(ctx, tokenID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, appErrors.ErrTokenInvalid
+		}
+		return nil, nil, err
+	}
+	if record.FamilyID != familyID || record.RevokedAt != nil {
+		return nil, nil, appErrors.ErrTokenInvalid
+	}
+
+	consumed, err := s.repo.MarkRefreshTokenUsed(ctx, record.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !consumed {
+		if err := s.repo.RevokeTokenFamily(ctx, record.FamilyID); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, appErrors.ErrTokenReused
+	}
+
+	user, err := s.users.GetByID(ctx, record.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, appErrors.ErrTokenInvalid
+		}
+		return nil, nil, err
+	}
+
+	tokens, err := s.issueTokens(ctx, user, record.FamilyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, tokens, nil
+}
+
+// Logout revokes the access token behind claims and, when given, the family
+// of the refresh token issued alongside it.
+func (s *authService) Logout(ctx context.Context, claims *auth.Claims, refreshToken string) error {
+	ctx, span := tracing.Start(ctx, "AuthService.Logout")
+	defer span.End()
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return appErrors.ErrTokenInvalid
+	}
+
+	if err := s.revocations.Revoke(ctx, claims.ID, userID, claims.ExpiresAt.Time); err != nil {
+		return err
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	refreshClaims, err := auth.ValidateToken(ctx, refreshToken, auth.TokenUseRefresh)
+	if err != nil {
+		// Already unusable, nothing left to revoke.
+		return nil
+	}
+	if refreshClaims.Subject != claims.Subject {
+		return appErrors.ErrForbidden
+	}
+
+	familyID, err := uuid.Parse(refreshClaims.FamilyID)
+	if err != nil {
+		return appErrors.ErrTokenInvalid
+	}
+	return s.repo.RevokeTokenFamily(ctx, familyID)
+}
+
+func (s *authService) LogoutAll(ctx context.Context, userID string) error {
+	ctx, span := tracing.Start(ctx, "AuthService.LogoutAll")
+	defer span.End()
+
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return appErrors.ErrTokenInvalid
+	}
+	return s.revokeAllSessions(ctx, id)
+}
+
+// revokeAllSessions invalidates every access and refresh token the user holds.
+// The cut-off keeps full precision: the iat claim is truncated to the second,
+// so every token issued before the cut-off has an iat below it. Tokens issued
+// later in the same second count as revoked too, see waitForNextSecond.
+func (s *authService) revokeAllSessions(ctx context.Context, userID uuid.UUID) error {
+	if err := s.repo.RevokeUserRefreshTokens(ctx, userID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return s.revocations.RevokeAllForUser(ctx, userID, now, now.Add(auth.RefreshTokenTTL))
+}
+
+// waitForNextSecond blocks until the next whole second, so tokens minted
+// after revokeAllSessions get an iat past its cut-off.
+func waitForNextSecond(ctx context.Context) error {
+	now := time.Now()
+	timer := time.NewTimer(now.Truncate(time.Second).Add(time.Second).Sub(now))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *authService) issueTokens(ctx context.Context, user *models.User, familyID uuid.UUID) (*auth.TokenPair, error) {
+	record := &models.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(auth.RefreshTokenTTL),
+	}
+	if err := s.repo.CreateRefreshToken(ctx, record); err != nil {
+		return nil, err
+	}
+
+	accessToken, err := auth.GenerateAccessToken(user.ID.String(), user.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := auth.GenerateRefreshToken(user.ID.String(), record.ID.String(), familyID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return &auth.TokenPair{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/auth"
+	appErrors "github.com/sudo-hassan-zahid/go-api-server/internal/errors"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/models"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/repository"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/tracing"
+	"gorm.io/gorm"
+)
+
+const (
+	recoveryCodeCount    = 10
+	recoveryCodeLength   = 10
+	recoveryCodeAlphabet = "abcdefghijklmnopqrstuvwxyz234567"
+
+	// maxMFAAttempts is how many wrong codes one mfa_pending token tolerates
+	// before it is revoked and the password has to be entered again. Confirm
+	// and Disable allow as many per user before locking for mfaLockout.
+	maxMFAAttempts = 5
+	mfaLockout     = 15 * time.Minute
+)
+
+type MFAEnrollment struct {
+	Secret string
+	URI    string
+}
+
+type MFAService interface {
+	Enroll(ctx context.Context, userID string) (*MFAEnrollment, error)
+	Confirm(ctx context.Context, userID, code string) ([]string, error)
+	Disable(ctx context.Context, userID, code string) error
+	Verify(ctx context.Context, mfaToken, code string) (*models.User, *auth.TokenPair, error)
+}
+
+type mfaService struct {
+	repo        repository.MFARepository
+	users       repository.UserRepository
+	revocations repository.RevocationRepository
+	auth        AuthService
+	issuer      string
+}
+
+func NewMFAService(
+	repo repository.MFARepository,
+	users repository.UserRepository,
+	revocations repository.RevocationRepository,
+	authService AuthService,
+	issuer string,
+) MFAService {
+	return &mfaService{repo: repo, users: users, revocations: revocations, auth: authService, issuer: issuer}
+}
+
+// Enroll generates a new TOTP secret for the user. It only becomes active
+// once a code generated from it is confirmed.
+func (s *mfaService) Enroll(ctx context.Context, userID string) (*MFAEnrollment, error) {
+	ctx, span := tracing.Start(ctx, "MFAService.Enroll")
+	defer span.End()
+
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, appErrors.ErrMFAAlreadyEnabled
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetTOTPSecret(ctx, user.ID, secret); err != nil {
+		return nil, err
+	}
+
+	return &MFAEnrollment{
+		Secret: secret,
+		URI:    auth.TOTPURI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+// Confirm enables two-factor authentication and returns a fresh set of
+// recovery codes. The codes are only ever shown here.
+func (s *mfaService) Confirm(ctx context.Context, userID, code string) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "MFAService.Confirm")
+	defer span.End()
+
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, appErrors.ErrMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, appErrors.ErrMFANotEnrolled
+	}
+	if err := s.countUserAttempt(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	ok, err := s.checkTOTP(ctx, user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, appErrors.ErrInvalidMFACode
+	}
+	if err := s.repo.ClearFailedAttempts(ctx, userAttemptKey(user.ID)); err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		if codes[i], err = newRecoveryCode(); err != nil {
+			return nil, err
+		}
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	if err := s.repo.EnableTOTP(ctx, user.ID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *mfaService) Disable(ctx context.Context, userID, code string) error {
+	ctx, span := tracing.Start(ctx, "MFAService.Disable")
+	defer span.End()
+
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return appErrors.ErrMFANotEnrolled
+	}
+	if err := s.countUserAttempt(ctx, user.ID); err != nil {
+		return err
+	}
+
+	if err := s.checkCode(ctx, user, code); err != nil {
+		return err
+	}
+	if err := s.repo.DisableTOTP(ctx, user.ID); err != nil {
+		return err
+	}
+	return s.repo.ClearFailedAttempts(ctx, userAttemptKey(user.ID))
+}
+
+// Verify completes a two-step login: it exchanges the mfa_pending token from
+// the password check plus a TOTP or recovery code for a regular token pair.
+func (s *mfaService) Verify(ctx context.Context, mfaToken, code string) (*models.User, *auth.TokenPair, error) {
+	ctx, span := tracing.Start(ctx, "MFAService.Verify")
+	defer span.End()
+
+	claims, err := auth.ValidateToken(ctx, mfaToken, auth.TokenUseMFAPending)
+	if err != nil {
+		return nil, nil, appErrors.ErrTokenInvalid
+	}
+
+	user, err := s.getUser(ctx, claims.Subject)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrUserNotFound) {
+			return nil, nil, appErrors.ErrTokenInvalid
+		}
+		return nil, nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, nil, appErrors.ErrTokenInvalid
+	}
+
+	if err := s.checkCode(ctx, user, code); err != nil {
+		if errors.Is(err, appErrors.ErrInvalidMFACode) {
+			return nil, nil, s.recordFailedAttempt(ctx, claims, user.ID)
+		}
+		return nil, nil, err
+	}
+
+	// The pending token is single use as well.
+	if err := s.revocations.Revoke(ctx, claims.ID, user.ID, claims.ExpiresAt.Time); err != nil {
+		return nil, nil, err
+	}
+
+	tokens, err := s.auth.IssueTokens(ctx, user)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, tokens, nil
+}
+
+// recordFailedAttempt counts a wrong code against the pending token and
+// revokes the token once maxMFAAttempts is reached. It returns the error to
+// report to the caller.
+func (s *mfaService) recordFailedAttempt(ctx context.Context, claims *auth.Claims, userID uuid.UUID) error {
+	failures, err := s.repo.RecordFailedAttempt(ctx, claims.ID, userID, claims.ExpiresAt.Time)
+	if err != nil {
+		return err
+	}
+	if failures < maxMFAAttempts {
+		return appErrors.ErrInvalidMFACode
+	}
+
+	if err := s.revocations.Revoke(ctx, claims.ID, userID, claims.ExpiresAt.Time); err != nil {
+		return err
+	}
+	return appErrors.ErrTokenInvalid
+}
+
+// countUserAttempt counts a code entered by a signed-in user as failed before
+// it is checked, so concurrent requests cannot get past the limit, and
+// refuses it once maxMFAAttempts were made within mfaLockout. A correct code
+// clears the count.
+func (s *mfaService) countUserAttempt(ctx context.Context, userID uuid.UUID) error {
+	failures, err := s.repo.RecordFailedAttempt(ctx, userAttemptKey(userID), userID, time.Now().Add(mfaLockout))
+	if err != nil {
+		return err
+	}
+	if failures > maxMFAAttempts {
+		return appErrors.ErrTooManyRequests
+	}
+	return nil
+}
+
+func userAttemptKey(userID uuid.UUID) string {
+	return "user:" + userID.String()
+}
+
+func (s *mfaService) getUser(ctx context.Context, userID string) (*models.User, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, appErrors.ErrUserNotFound
+	}
+
+	user, err := s.users.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// checkCode accepts either a current TOTP code or an unused recovery code.
+func (s *mfaService) checkCode(ctx context.Context, user *models.User, code string) error {
+	code = strings.TrimSpace(code)
+
+	ok, err := s.checkTOTP(ctx, user, code)
+	if err != nil {
+		return err
+	}
+	if !ok && len(code) != auth.TOTPDigits {
+		ok, err = s.repo.ConsumeRecoveryCode(ctx, user.ID, hashRecoveryCode(code))
+		if err != nil {
+			return err
+		}
+	}
+
+	if !ok {
+		return appErrors.ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (s *mfaService) checkTOTP(ctx context.Context, user *models.User, code string) (bool, error) {
+	step, ok := auth.ValidateTOTP(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+	return s.repo.AdvanceTOTPStep(ctx, user.ID, step)
+}
+
+func newRecoveryCode() (string, error) {
+	b := make([]byte, recoveryCodeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = recoveryCodeAlphabet[int(b[i])%len(recoveryCodeAlphabet)]
+	}
+	half := recoveryCodeLength / 2
+	return string(b[:half]) + "-" + string(b[half:]), nil
+}
+
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return auth.HashOpaqueToken(normalized)
+}
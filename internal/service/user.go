@@ -1,35 +1,161 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/auth"
+	dto "github.com/sudo-hassan-zahid/go-api-server/internal/dto"
 	appErrors "github.com/sudo-hassan-zahid/go-api-server/internal/errors"
 
 	"github.com/sudo-hassan-zahid/go-api-server/internal/models"
 	"github.com/sudo-hassan-zahid/go-api-server/internal/repository"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/tracing"
+	"github.com/sudo-hassan-zahid/go-api-server/utils"
 	"gorm.io/gorm"
 )
 
 type UserService interface {
-	GetAllUsers() ([]models.User, error)
-	GetUserByID(id uint) (*models.User, error)
+	ListUsers(ctx context.Context, filter repository.UserFilter, opts repository.UserListOptions) ([]models.User, int64, *repository.Cursor, error)
+	GetUserByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	UpdateUser(ctx context.Context, id uuid.UUID, req dto.UpdateUserRequest, canChangeRole bool) (*models.User, error)
+	DeleteUser(ctx context.Context, id uuid.UUID) error
+	RestoreUser(ctx context.Context, id uuid.UUID) (*models.User, error)
+	SearchUsers(ctx context.Context, query string, limit int) ([]repository.UserSearchResult, error)
 }
 
 type userService struct {
 	repo repository.UserRepository
+	auth AuthService
 	db   *gorm.DB
 }
 
-func NewUserService(repo repository.UserRepository, db *gorm.DB) UserService {
-	return &userService{repo: repo, db: db}
+func NewUserService(repo repository.UserRepository, authService AuthService, db *gorm.DB) UserService {
+	return &userService{repo: repo, auth: authService, db: db}
 }
 
-func (s *userService) GetAllUsers() ([]models.User, error) {
-	return s.repo.GetAll()
+func (s *userService) ListUsers(ctx context.Context, filter repository.UserFilter, opts repository.UserListOptions) ([]models.User, int64, *repository.Cursor, error) {
+	ctx, span := tracing.Start(ctx, "UserService.ListUsers")
+	defer span.End()
+
+	if filter.Role != "" && !auth.RoleExists(filter.Role) {
+		return nil, 0, nil, appErrors.ErrBadRequest
+	}
+
+	users, total, next, err := s.repo.List(ctx, filter, opts)
+	if errors.Is(err, repository.ErrInvalidSort) {
+		return nil, 0, nil, appErrors.ErrBadRequest
+	}
+	return users, total, next, err
 }
 
-func (s *userService) GetUserByID(id uint) (*models.User, error) {
-	user, err := s.repo.GetByID(id)
+func (s *userService) GetUserByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserByID")
+	defer span.End()
+
+	user, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		return nil, appErrors.ErrUserNotFound
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrUserNotFound
+		}
+		return nil, err
 	}
 	return user, nil
 }
+
+// UpdateUser applies the non-empty fields of req. Changing the role requires
+// canChangeRole, and a new email address has to be verified again. Setting a
+// password or changing the role signs the user out everywhere, as the role is
+// baked into live access tokens.
+func (s *userService) UpdateUser(ctx context.Context, id uuid.UUID, req dto.UpdateUserRequest, canChangeRole bool) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateUser")
+	defer span.End()
+
+	user, err := s.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]interface{}{}
+	if req.FirstName != "" {
+		fields["first_name"] = utils.SanitizeString(req.FirstName)
+	}
+	if req.LastName != "" {
+		fields["last_name"] = utils.SanitizeString(req.LastName)
+	}
+	if req.Password != "" {
+		fields["password"] = req.Password
+	}
+	if email := utils.SanitizeEmail(req.Email); email != "" && email != user.Email {
+		taken, err := s.repo.EmailTaken(ctx, email, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, appErrors.ErrEmailAlreadyExists
+		}
+		fields["email"] = email
+		fields["is_verified"] = false
+	}
+	if req.Role != "" && req.Role != user.Role {
+		if !canChangeRole {
+			return nil, appErrors.ErrForbidden
+		}
+		if !auth.RoleExists(req.Role) {
+			return nil, appErrors.ErrBadRequest
+		}
+		fields["role"] = req.Role
+	}
+
+	if len(fields) == 0 {
+		return user, nil
+	}
+	if err := s.repo.Update(ctx, user, fields); err != nil {
+		return nil, err
+	}
+	_, passwordSet := fields["password"]
+	_, roleChanged := fields["role"]
+	if passwordSet || roleChanged {
+		if err := s.auth.LogoutAll(ctx, id.String()); err != nil {
+			return nil, err
+		}
+	}
+	return s.GetUserByID(ctx, id)
+}
+
+// DeleteUser soft deletes the account and revokes its sessions; it can be
+// brought back with RestoreUser.
+func (s *userService) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "UserService.DeleteUser")
+	defer span.End()
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return appErrors.ErrUserNotFound
+		}
+		return err
+	}
+	return s.auth.LogoutAll(ctx, id.String())
+}
+
+func (s *userService) RestoreUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.RestoreUser")
+	defer span.End()
+
+	if err := s.repo.Restore(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrUserNotFound
+		}
+		return nil, err
+	}
+	return s.GetUserByID(ctx, id)
+}
+
+func (s *userService) SearchUsers(ctx context.Context, query string, limit int) ([]repository.UserSearchResult, error) {
+	ctx, span := tracing.Start(ctx, "UserService.SearchUsers")
+	defer span.End()
+
+	return s.repo.Search(ctx, strings.TrimSpace(query), limit)
+}
@@ -0,0 +1,77 @@
+package tracing
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/middleware"
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware opens a server span around the rest of the middleware chain,
+// continuing the trace of an incoming traceparent header. The span context is
+// stored as the request's user context; handlers pass c.UserContext() on to
+// services so their spans and queries join the request's trace.
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), headerCarrier{&c.Request().Header})
+		ctx, span := Start(ctx, c.Method()+" "+c.Path(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Method()),
+				semconv.URLPath(c.Path()),
+				semconv.URLScheme(c.Protocol()),
+				semconv.ClientAddress(middleware.ClientIP(c)),
+				semconv.UserAgentOriginal(c.Get(fiber.HeaderUserAgent)),
+			),
+		)
+		defer span.End()
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if e, ok := err.(*fiber.Error); ok {
+			status = e.Code
+		} else if err != nil {
+			status = fiber.StatusInternalServerError
+		}
+
+		// The route template is only known once routing has happened.
+		if route := c.Route().Path; route != "" {
+			span.SetName(c.Method() + " " + route)
+			span.SetAttributes(semconv.HTTPRoute(route))
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if err != nil {
+			span.RecordError(err)
+		}
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, "")
+		}
+		return err
+	}
+}
+
+// headerCarrier adapts fasthttp request headers to propagation.TextMapCarrier.
+type headerCarrier struct {
+	h *fasthttp.RequestHeader
+}
+
+func (c headerCarrier) Get(key string) string {
+	return string(c.h.Peek(key))
+}
+
+func (c headerCarrier) Set(key, value string) {
+	c.h.Set(key, value)
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, c.h.Len())
+	c.h.VisitAll(func(k, _ []byte) {
+		keys = append(keys, string(k))
+	})
+	return keys
+}
@@ -1,24 +1,20 @@
 package routes
 
 import (
+	"github.com/sudo-hassan-zahid/go-api-server/internal/config"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/constants"
 	"github.com/sudo-hassan-zahid/go-api-server/internal/handler"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/health"
+	"github.com/sudo-hassan-zahid/go-api-server/internal/mailer"
 	"github.com/sudo-hassan-zahid/go-api-server/internal/middleware"
 	"github.com/sudo-hassan-zahid/go-api-server/internal/repository"
 	"github.com/sudo-hassan-zahid/go-api-server/internal/service"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/cors"
 	"gorm.io/gorm"
 )
 
-func Setup(app *fiber.App, db *gorm.DB) {
-	// CORS configuration
-	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*",
-		AllowMethods: "GET,POST,PUT,PATCH,DELETE,OPTIONS",
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
-	}))
-
+func Setup(app *fiber.App, db *gorm.DB, cfg *config.Config, mail mailer.Mailer, checks *health.Registry) {
 	// JWT auth
 	jwt := middleware.JWTMiddleware()
 
@@ -27,21 +23,58 @@ func Setup(app *fiber.App, db *gorm.DB) {
 
 	// Auth APIs
 	authRepo := repository.NewAuthRepository(db)
-	authService := service.NewAuthService(authRepo, db)
+	userRepo := repository.NewUserRepository(db)
+	revocationRepo := repository.NewRevocationRepository(db)
+	authService := service.NewAuthService(authRepo, userRepo, revocationRepo, mail, cfg.Auth)
 	authHandler := handler.NewAuthHandler(authService)
 	auth := api.Group("/auth")
 	auth.Post("/signup", authHandler.CreateUser)
 	auth.Post("/login", authHandler.LoginUser)
+	auth.Post("/refresh", authHandler.RefreshToken)
+	auth.Post("/verify", authHandler.VerifyEmail)
+	auth.Post("/verify/resend", authHandler.ResendVerification)
+	auth.Post("/password/forgot", authHandler.ForgotPassword)
+	auth.Post("/password/reset", authHandler.ResetPassword)
+	auth.Post("/logout", jwt, authHandler.Logout)
+	auth.Post("/logout-all", jwt, authHandler.LogoutAll)
+
+	// MFA APIs
+	mfaRepo := repository.NewMFARepository(db)
+	mfaService := service.NewMFAService(mfaRepo, userRepo, revocationRepo, authService, cfg.App.Name)
+	mfaHandler := handler.NewMFAHandler(mfaService)
+	mfa := auth.Group("/mfa")
+	mfa.Post("/enroll", jwt, mfaHandler.Enroll)
+	mfa.Post("/confirm", jwt, mfaHandler.Confirm)
+	mfa.Post("/disable", jwt, mfaHandler.Disable)
+	mfa.Post("/verify", mfaHandler.Verify)
 
 	// User APIs
-	userRepo := repository.NewUserRepository(db)
-	userService := service.NewUserService(userRepo, db)
+	userService := service.NewUserService(userRepo, authService, db)
 	userHandler := handler.NewUserHandler(userService)
 	users := api.Group("/users")
-	users.Get("/", jwt, userHandler.GetAllUsers)
+	users.Get("/", jwt, middleware.RequirePermission(constants.PERM_USERS_READ), userHandler.ListUsers)
+	users.Get("/search", jwt, middleware.RequirePermission(constants.PERM_USERS_READ), userHandler.SearchUsers)
 	users.Get("/:id", jwt, userHandler.GetUserByID)
+	users.Patch("/:id", jwt, userHandler.UpdateUser)
+	users.Delete("/:id", jwt, middleware.RequirePermission(constants.PERM_USERS_WRITE), userHandler.DeleteUser)
+	users.Post("/:id/restore", jwt, middleware.RequirePermission(constants.PERM_USERS_WRITE), userHandler.RestoreUser)
+
+	// Current user APIs
+	meHandler := handler.NewMeHandler(userService, authService)
+	me := api.Group("/me", jwt)
+	me.Get("/", meHandler.GetMe)
+	me.Patch("/", meHandler.UpdateMe)
+	me.Delete("/", meHandler.DeleteMe)
+	me.Post("/password", meHandler.ChangePassword)
 
 	// Public routes
 	publicHandler := handler.NewPublicHandler()
 	api.Get("/health", publicHandler.HealthCheck)
+	api.Get("/features", publicHandler.Features)
+	app.Get("/.well-known/jwks.json", publicHandler.JWKS)
+
+	// Probes, outside /api like the orchestrators expect
+	healthHandler := handler.NewHealthHandler(checks)
+	app.Get("/livez", healthHandler.Livez)
+	app.Get("/readyz", healthHandler.Readyz)
 }
@@ -14,7 +14,11 @@ func ValidateStruct(c *fiber.Ctx, s interface{}) bool {
 		for _, e := range err.(validator.ValidationErrors) {
 			errors[e.Field()] = e.Error()
 		}
-		c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"errors": errors})
+		body := fiber.Map{"errors": errors}
+		if id, ok := c.Locals("requestID").(string); ok {
+			body["request_id"] = id
+		}
+		c.Status(fiber.StatusBadRequest).JSON(body)
 		return false
 	}
 	return true